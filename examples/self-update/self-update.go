@@ -0,0 +1,68 @@
+// Copyright (C) 2024 vcs contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/jaredallard/vcs/selfupdate"
+)
+
+// version is the version of this binary, usually set at build time
+// (e.g., with -ldflags "-X main.version=v1.0.0").
+var version = "v0.0.1"
+
+// main updates this binary to the latest release of stencil on Github,
+// as if it were stencil itself. The asset for the GOOS and GOARCH of
+// the current system is downloaded, verified against the checksums of
+// the release and then swapped in place of the running executable.
+//
+// Under the hood, the VCS provider is being determined from the URL and
+// authentication is being provided for the determined VCS provider if
+// it was configured on the system.
+func main() {
+	ctx := context.Background()
+
+	u, err := selfupdate.Check(ctx, &selfupdate.Options{
+		RepoURL:        "https://github.com/rgst-io/stencil",
+		CurrentVersion: version,
+
+		// Archives contain a "stencil" binary, not one named after this
+		// example.
+		BinaryName: "stencil",
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	if !u.Available {
+		fmt.Println("Already running the latest version", u.Current)
+		return
+	}
+
+	fmt.Println("Updating from", u.Current, "to", u.Latest.Tag)
+	if err := u.Apply(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, "Failed to update:", err)
+		os.Exit(1)
+	}
+
+	fmt.Println("Updated to", u.Latest.Tag)
+}
@@ -29,7 +29,9 @@ import (
 	"os"
 	"os/exec"
 	"regexp"
+	"slices"
 	"strings"
+	"sync"
 
 	"github.com/jaredallard/cmdexec"
 	"github.com/jaredallard/vcs"
@@ -45,20 +47,62 @@ var (
 
 	// ErrNoRemoteHeadBranch is returned when a repository's remote  default/HEAD branch
 	// cannot be determined.
-	ErrNoRemoteHeadBranch = errors.New("failed to get head branch from remote origin")
+	ErrNoRemoteHeadBranch = errors.New("failed to get head branch from remote")
+
+	// ErrNoRemotes is returned when a repository has no remotes.
+	ErrNoRemotes = errors.New("repository has no remotes")
 
 	// headPattern is used to parse git output to determine the head branch
 	headPattern = regexp.MustCompile(`HEAD branch: ([[:alpha:]]+)`)
+
+	// packetPattern is used to parse packets received by the client from
+	// GIT_TRACE_PACKET output.
+	packetPattern = regexp.MustCompile(`packet:\s+(\S+)< (.*)$`)
+
+	// capabilitiesCache contains the [Capabilities] of remotes that were
+	// probed by this process, keyed by their canonical URL (see
+	// [CanonicalURL]).
+	capabilitiesCache sync.Map
 )
 
+// GetDefaultBranchOptions contains options accepted by
+// [GetDefaultBranch].
+type GetDefaultBranchOptions struct {
+	// Remote is the name of the remote to determine the default branch
+	// of. If not set, the primary remote is used (see [PrimaryRemote]).
+	Remote string
+}
+
 // GetDefaultBranch determines the default/HEAD branch for a given git
 // repository.
-func GetDefaultBranch(ctx context.Context, path string) (string, error) {
-	cmd := exec.CommandContext(ctx, "git", "remote", "show", "origin")
+//
+// optss is a variadic argument only to avoid a breaking change. Only
+// one option struct is allowed, an error will be returned if more than
+// one is provided.
+func GetDefaultBranch(ctx context.Context, path string, optss ...*GetDefaultBranchOptions) (string, error) {
+	var opts GetDefaultBranchOptions
+	if len(optss) == 1 {
+		if optss[0] != nil {
+			opts = *optss[0]
+		}
+	} else if len(optss) > 1 {
+		return "", fmt.Errorf("too many options provided")
+	}
+
+	remote := opts.Remote
+	if remote == "" {
+		var err error
+		remote, err = PrimaryRemote(ctx, path)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	cmd := exec.CommandContext(ctx, "git", "remote", "show", remote)
 	cmd.Dir = path
 	out, err := cmd.Output()
 	if err != nil {
-		return "", errors.Wrap(err, "failed to get head branch from remote origin")
+		return "", errors.Wrapf(err, "failed to get head branch from remote %s", remote)
 	}
 
 	matches := headPattern.FindStringSubmatch(string(out))
@@ -69,6 +113,51 @@ func GetDefaultBranch(ctx context.Context, path string) (string, error) {
 	return matches[1], nil
 }
 
+// PrimaryRemote returns the name of the primary remote of the git
+// repository at path. This is the first of the following that exists:
+//
+//   - The remote of the upstream of the current branch
+//   - A remote named "origin"
+//   - The first remote (as sorted by git)
+//
+// If the repository has no remotes, [ErrNoRemotes] is returned.
+func PrimaryRemote(ctx context.Context, path string) (string, error) {
+	cmd := cmdexec.CommandContext(ctx, "git", "remote")
+	cmd.SetDir(path)
+	out, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to list remotes: %w", execerr.From(err))
+	}
+
+	remotes := strings.Fields(string(out))
+	if len(remotes) == 0 {
+		return "", ErrNoRemotes
+	}
+
+	// Use the remote of the upstream of the current branch, if there is
+	// one. Errors are ignored since a detached HEAD or no upstream is
+	// not an error for our purposes.
+	cmd = cmdexec.CommandContext(ctx, "git", "symbolic-ref", "--quiet", "--short", "HEAD")
+	cmd.SetDir(path)
+	if out, err := cmd.Output(); err == nil {
+		branch := strings.TrimSpace(string(out))
+
+		cmd = cmdexec.CommandContext(ctx, "git", "config", "--get", "branch."+branch+".remote")
+		cmd.SetDir(path)
+		if out, err := cmd.Output(); err == nil {
+			if remote := strings.TrimSpace(string(out)); slices.Contains(remotes, remote) {
+				return remote, nil
+			}
+		}
+	}
+
+	if slices.Contains(remotes, "origin") {
+		return "origin", nil
+	}
+
+	return remotes[0], nil
+}
+
 // CloneOptions contains options accepted by [Clone].
 type CloneOptions struct {
 	// UseArchive fetches the references using a tarball from the
@@ -140,10 +229,107 @@ func Clone(ctx context.Context, ref, url string, optss ...*CloneOptions) (string
 	return tempDir, nil
 }
 
+// ListRemoteOptions contains options accepted by [ListRemote].
+type ListRemoteOptions struct {
+	// Snapshot persists the output of a successful 'git ls-remote' to
+	// the cache directory, keyed by the canonical URL of the remote (see
+	// [CanonicalURL]). Snapshots are used by Offline and
+	// FallbackToSnapshot. Persisting is best effort: if the snapshot
+	// can't be written, the output is still returned.
+	Snapshot bool
+
+	// Offline serves the output from a previously persisted snapshot
+	// without accessing the network. If no snapshot exists,
+	// [ErrNoSnapshot] is returned.
+	Offline bool
+
+	// FallbackToSnapshot serves the output from a previously persisted
+	// snapshot if running 'git ls-remote' fails (e.g., the network is
+	// unavailable). If no snapshot exists, the original error is
+	// returned.
+	FallbackToSnapshot bool
+
+	// CacheDir is the directory that snapshots are stored in. Defaults to
+	// "ls-remote" inside of the shared cache directory.
+	CacheDir string
+
+	// ReuseConnections multiplexes SSH connections to the same host
+	// through a single, shared connection (OpenSSH's ControlMaster) that
+	// is kept open for a short while after use. This avoids paying for a
+	// new SSH handshake on every call, which adds up when listing many
+	// repositories on the same host (see [ListRemotes]).
+	//
+	// This is not done if an SSH command has already been configured
+	// (GIT_SSH_COMMAND, GIT_SSH or core.sshCommand) or on Windows. HTTP
+	// remotes are unaffected, as connections cannot be shared between
+	// separate git processes.
+	ReuseConnections bool
+}
+
 // ListRemote returns a list of all remotes as shown from running 'git
 // ls-remote'.
-func ListRemote(ctx context.Context, remote string) ([][]string, error) {
+//
+// optss is a variadic argument only to avoid a breaking change. Only
+// one option struct is allowed, an error will be returned if more than
+// one is provided.
+func ListRemote(ctx context.Context, remote string, optss ...*ListRemoteOptions) ([][]string, error) {
+	var opts ListRemoteOptions
+	if len(optss) == 1 {
+		if optss[0] != nil {
+			opts = *optss[0]
+		}
+	} else if len(optss) > 1 {
+		return nil, fmt.Errorf("too many options provided")
+	}
+
+	var env []string
+	if opts.ReuseConnections && !opts.Offline {
+		env = sshMultiplexEnv(ctx)
+	}
+
+	return listRemoteWithOptions(ctx, remote, &opts, env)
+}
+
+// listRemoteWithOptions implements [ListRemote] for already validated
+// options. env contains additional environment variables to run 'git
+// ls-remote' with.
+func listRemoteWithOptions(ctx context.Context, remote string, opts *ListRemoteOptions, env []string) ([][]string, error) {
+	if opts.Offline {
+		snap, err := ReadSnapshot(remote, opts)
+		if err != nil {
+			return nil, err
+		}
+		return snap.Remotes, nil
+	}
+
+	remotes, err := listRemote(ctx, remote, env)
+	if err != nil {
+		if opts.FallbackToSnapshot {
+			if snap, serr := ReadSnapshot(remote, opts); serr == nil {
+				return snap.Remotes, nil
+			}
+		}
+
+		return nil, err
+	}
+
+	if opts.Snapshot {
+		// Persisting is best effort, the cache directory may not be
+		// writable (e.g., in CI) and we already have what was asked for.
+		writeSnapshot(remote, remotes, opts) //nolint:errcheck // Why: Best effort.
+	}
+
+	return remotes, nil
+}
+
+// listRemote runs 'git ls-remote' against the provided remote and
+// parses the output. env contains additional environment variables to
+// run it with, if any.
+func listRemote(ctx context.Context, remote string, env []string) ([][]string, error) {
 	cmd := cmdexec.CommandContext(ctx, "git", "ls-remote", remote)
+	if len(env) != 0 {
+		cmd.SetEnviron(append(os.Environ(), env...))
+	}
 	out, err := cmd.Output()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get remote branches: %w", execerr.From(err))
@@ -166,3 +352,229 @@ func ListRemote(ctx context.Context, remote string) ([][]string, error) {
 
 	return remotes, nil
 }
+
+// ListTagsOnBranch returns the names of all tags on the provided remote
+// that are reachable from the provided branch (i.e., tags that were cut
+// from the branch or one of its ancestors).
+//
+// In order to inspect ancestry, the branch and all tag refs are fetched
+// into a temporary bare repository. Only commits are fetched (a
+// "treeless" fetch), so this is much cheaper than a full clone. Servers
+// that don't support filtering (see [Capabilities.SupportsFilter]), or
+// whose capabilities can't be determined, are sent everything instead.
+// The capabilities of a remote are only determined once per process.
+func ListTagsOnBranch(ctx context.Context, remote, branch string) ([]string, error) {
+	tempDir, err := os.MkdirTemp("", "vcs-tags-on-branch-")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create temporary directory")
+	}
+	defer os.RemoveAll(tempDir)
+
+	// Only do a treeless fetch if the server supports it. If we can't
+	// determine the capabilities, assume it's not supported.
+	fetchArgs := []string{"git", "-c", "protocol.version=2", "fetch", "--no-tags"}
+	if caps, err := cachedRemoteCapabilities(ctx, remote); err == nil && caps.SupportsFilter() {
+		fetchArgs = append(fetchArgs, "--filter=tree:0")
+	}
+
+	branchRef := "refs/heads/" + branch
+	cmds := [][]string{
+		{"git", "init", "--bare"},
+		append(fetchArgs, remote, "+"+branchRef+":"+branchRef, "+refs/tags/*:refs/tags/*"),
+	}
+	for _, cmd := range cmds {
+		//nolint:gosec // Why: Commands are not user provided.
+		c := cmdexec.CommandContext(ctx, cmd[0], cmd[1:]...)
+		c.SetDir(tempDir)
+		if _, err := c.Output(); err != nil {
+			return nil, fmt.Errorf("failed to run %q: %w", cmd, execerr.From(err))
+		}
+	}
+
+	c := cmdexec.CommandContext(ctx,
+		"git", "for-each-ref", "--merged", branchRef, "--format=%(refname:strip=2)", "refs/tags",
+	)
+	c.SetDir(tempDir)
+	out, err := c.Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags merged into %s: %w", branch, execerr.From(err))
+	}
+
+	tags := make([]string, 0)
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		if tag := strings.TrimSpace(scanner.Text()); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
+// Capabilities contains the capabilities advertised by a Git server.
+// See [RemoteCapabilities].
+type Capabilities struct {
+	// ProtocolVersion is the version of the Git wire protocol that was
+	// negotiated with the server (0, 1 or 2). Version 1 is the same as 0
+	// with the addition of a version announcement.
+	ProtocolVersion int
+
+	// Capabilities is a map of capability names to their value, if
+	// they have one. For protocol v2, this is the command (e.g., "fetch")
+	// to the features it supports (e.g., "shallow filter").
+	Capabilities map[string]string
+
+	// Symrefs is a map of symbolic references to the reference they
+	// point to (e.g., HEAD -> refs/heads/main).
+	Symrefs map[string]string
+}
+
+// Has returns true if the server advertised the provided capability.
+func (c *Capabilities) Has(name string) bool {
+	_, ok := c.Capabilities[name]
+	return ok
+}
+
+// Agent returns the agent string advertised by the server, if any.
+func (c *Capabilities) Agent() string {
+	return c.Capabilities["agent"]
+}
+
+// SupportsFilter returns true if the server supports partial clones
+// (e.g., --filter=blob:none).
+func (c *Capabilities) SupportsFilter() bool {
+	if c.ProtocolVersion == 2 {
+		return slices.Contains(strings.Fields(c.Capabilities["fetch"]), "filter")
+	}
+
+	return c.Has("filter")
+}
+
+// RemoteCapabilities returns the capabilities advertised by the
+// provided remote. Protocol v2 is requested, but servers that do not
+// support it (or have it disabled) will report an older version.
+func RemoteCapabilities(ctx context.Context, remote string) (*Capabilities, error) {
+	var stderr bytes.Buffer
+	cmd := cmdexec.CommandContext(ctx, "git", "-c", "protocol.version=2", "ls-remote", "--symref", remote, "HEAD")
+	cmd.SetEnviron(append(os.Environ(), "GIT_TRACE_PACKET=1"))
+	cmd.SetStderr(&stderr)
+	out, err := cmd.Output()
+	if err != nil {
+		// Stderr is captured for tracing, so it isn't set on the error.
+		var execErr *exec.ExitError
+		if errors.As(err, &execErr) {
+			execErr.Stderr = stripTrace(stderr.String())
+		}
+
+		return nil, fmt.Errorf("failed to get remote capabilities: %w", execerr.From(err))
+	}
+
+	caps := parseCapabilities(stderr.String())
+
+	// ref: refs/heads/main	HEAD
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		target, ok := strings.CutPrefix(scanner.Text(), "ref: ")
+		if !ok {
+			continue
+		}
+
+		target, name, ok := strings.Cut(target, "\t")
+		if !ok {
+			continue
+		}
+		caps.Symrefs[name] = target
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return caps, nil
+}
+
+// cachedRemoteCapabilities returns [RemoteCapabilities] for the
+// provided remote, only probing it once per process. Failures are not
+// cached.
+func cachedRemoteCapabilities(ctx context.Context, remote string) (*Capabilities, error) {
+	key := CanonicalURL(remote)
+	if caps, ok := capabilitiesCache.Load(key); ok {
+		return caps.(*Capabilities), nil
+	}
+
+	caps, err := RemoteCapabilities(ctx, remote)
+	if err != nil {
+		return nil, err
+	}
+
+	capabilitiesCache.Store(key, caps)
+	return caps, nil
+}
+
+// parseCapabilities parses the capabilities received by the client
+// from the provided GIT_TRACE_PACKET output.
+func parseCapabilities(trace string) *Capabilities {
+	caps := &Capabilities{
+		Capabilities: make(map[string]string),
+		Symrefs:      make(map[string]string),
+	}
+
+	packets := make([]string, 0)
+	for _, line := range strings.Split(trace, "\n") {
+		matches := packetPattern.FindStringSubmatch(line)
+		if len(matches) != 3 || matches[1] == "upload-pack" {
+			continue
+		}
+		packets = append(packets, matches[2])
+	}
+
+	for i, packet := range packets {
+		switch {
+		case packet == "version 2":
+			// Protocol v2 advertises one capability per packet, until a
+			// flush packet.
+			caps.ProtocolVersion = 2
+			for _, capability := range packets[i+1:] {
+				if capability == "0000" {
+					break
+				}
+
+				name, value, _ := strings.Cut(capability, "=")
+				caps.Capabilities[name] = value
+			}
+			return caps
+		case packet == "version 1":
+			caps.ProtocolVersion = 1
+		case strings.Contains(packet, `\0`):
+			// Protocol v0/v1 advertise all capabilities after a NUL on the
+			// first reference.
+			_, capabilities, _ := strings.Cut(packet, `\0`)
+			for _, capability := range strings.Fields(capabilities) {
+				name, value, _ := strings.Cut(capability, "=")
+				if name == "symref" {
+					if from, to, ok := strings.Cut(value, ":"); ok {
+						caps.Symrefs[from] = to
+					}
+				}
+				caps.Capabilities[name] = value
+			}
+			return caps
+		}
+	}
+
+	return caps
+}
+
+// stripTrace returns the provided stderr output without the packets
+// written to it by GIT_TRACE_PACKET.
+func stripTrace(stderr string) []byte {
+	var b bytes.Buffer
+	for _, line := range strings.SplitAfter(stderr, "\n") {
+		if !strings.Contains(line, "packet:") {
+			b.WriteString(line)
+		}
+	}
+	return b.Bytes()
+}
@@ -0,0 +1,157 @@
+// Copyright (C) 2024 vcs contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package git
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/jaredallard/cmdexec"
+	"github.com/jaredallard/vcs/internal/cachedir"
+)
+
+// DefaultConcurrency is the number of remotes listed at the same time
+// by [ListRemotes] when no concurrency is provided.
+const DefaultConcurrency = 8
+
+// sshControlPersist is how long a multiplexed SSH connection is kept
+// open after the last command using it has finished.
+const sshControlPersist = "60s"
+
+// ListRemoteResult is the result of listing a single remote with
+// [ListRemotes].
+type ListRemoteResult struct {
+	// Remote is the remote that was listed.
+	Remote string
+
+	// Remotes is the output of [ListRemote] for the remote.
+	Remotes [][]string
+
+	// Err is the error returned by [ListRemote] for the remote, if any.
+	Err error
+}
+
+// ListRemotes runs [ListRemote] for all of the provided remotes, using
+// at most concurrency 'git ls-remote' processes at a time. If
+// concurrency is <= 0, [DefaultConcurrency] is used. Results are
+// returned in the same order as the provided remotes. Failing to list
+// a remote does not stop the others from being listed, check the Err
+// field of each result.
+//
+// When listing many repositories on the same host, consider setting
+// [ListRemoteOptions.ReuseConnections] to avoid a new SSH handshake per
+// repository.
+//
+// optss is a variadic argument only to be consistent with
+// [ListRemote]. Only one option struct is allowed, an error will be
+// returned if more than one is provided.
+func ListRemotes(
+	ctx context.Context, remotes []string, concurrency int, optss ...*ListRemoteOptions,
+) ([]ListRemoteResult, error) {
+	var opts ListRemoteOptions
+	if len(optss) == 1 {
+		if optss[0] != nil {
+			opts = *optss[0]
+		}
+	} else if len(optss) > 1 {
+		return nil, fmt.Errorf("too many options provided")
+	}
+
+	if concurrency <= 0 {
+		concurrency = DefaultConcurrency
+	}
+
+	// Determine the environment once, rather than for every remote.
+	var env []string
+	if opts.ReuseConnections && !opts.Offline {
+		env = sshMultiplexEnv(ctx)
+	}
+
+	results := make([]ListRemoteResult, len(remotes))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, remote := range remotes {
+		results[i].Remote = remote
+
+		select {
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+			continue
+		case sem <- struct{}{}:
+		}
+
+		wg.Add(1)
+		go func(res *ListRemoteResult) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			res.Remotes, res.Err = listRemoteWithOptions(ctx, res.Remote, &opts, env)
+		}(&results[i])
+	}
+	wg.Wait()
+
+	return results, nil
+}
+
+// sshMultiplexEnv returns the environment variables needed to make git
+// multiplex SSH connections to the same host, or nil if an SSH command
+// has already been configured or multiplexing isn't supported.
+func sshMultiplexEnv(ctx context.Context) []string {
+	// OpenSSH on Windows does not support ControlMaster.
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+
+	// Never override an SSH command that was explicitly configured.
+	if os.Getenv("GIT_SSH_COMMAND") != "" || os.Getenv("GIT_SSH") != "" {
+		return nil
+	}
+	out, err := cmdexec.CommandContext(ctx, "git", "config", "--get", "core.sshCommand").Output()
+	if err == nil && strings.TrimSpace(string(out)) != "" {
+		return nil
+	}
+
+	dir, err := cachedir.Dir("ssh")
+	if err != nil {
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil
+	}
+
+	// %C is a hash of the connection's host, port and user, which keeps
+	// the socket path short enough for unix sockets. The path is quoted
+	// for ssh as well, since it may contain spaces.
+	controlPath := filepath.Join(dir, "%C")
+	return []string{fmt.Sprintf(
+		"GIT_SSH_COMMAND=ssh -o ControlMaster=auto -o %s -o ControlPersist=%s",
+		shellQuote(`ControlPath="`+controlPath+`"`), sshControlPersist,
+	)}
+}
+
+// shellQuote quotes s for use as a single argument in a POSIX shell
+// command, as GIT_SSH_COMMAND is interpreted by a shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
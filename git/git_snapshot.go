@@ -0,0 +1,168 @@
+// Copyright (C) 2024 vcs contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+// Description: Contains on-disk snapshots of 'git ls-remote' output.
+
+package git
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jaredallard/vcs"
+	"github.com/jaredallard/vcs/internal/cachedir"
+)
+
+// ErrNoSnapshot is returned when no snapshot of a remote exists.
+var ErrNoSnapshot = errors.New("no ls-remote snapshot found for remote")
+
+// Snapshot is a persisted copy of the output of [ListRemote] for a
+// remote. See [ListRemoteOptions.Snapshot].
+type Snapshot struct {
+	// URL is the canonical URL of the remote, see [CanonicalURL].
+	URL string `json:"url"`
+
+	// FetchedAt is when the snapshot was taken.
+	FetchedAt time.Time `json:"fetched_at"`
+
+	// Remotes is the output of [ListRemote] at the time of the
+	// snapshot.
+	Remotes [][]string `json:"remotes"`
+}
+
+// CanonicalURL returns a canonical form of the provided remote URL so
+// that different URLs pointing to the same repository are treated the
+// same (e.g., "git@github.com:org/repo.git" and
+// "https://github.com/org/repo" both become "github.com/org/repo").
+// Ports are kept and local paths are made absolute.
+func CanonicalURL(remote string) string {
+	u, err := vcs.ParseRepoURL(remote)
+	if err != nil {
+		if abs, err := filepath.Abs(remote); err == nil {
+			return abs
+		}
+		return remote
+	}
+
+	host := u.Host
+	switch {
+	case u.Port != "":
+		host = net.JoinHostPort(u.Host, u.Port)
+	case strings.Contains(host, ":"): // IPv6
+		host = "[" + host + "]"
+	}
+	return host + "/" + u.Path
+}
+
+// snapshotPath returns the path to the snapshot for the provided
+// remote.
+func snapshotPath(remote string, opts *ListRemoteOptions) (string, error) {
+	dir := opts.CacheDir
+	if dir == "" {
+		var err error
+		dir, err = cachedir.Dir("ls-remote")
+		if err != nil {
+			return "", err
+		}
+	}
+
+	hash := sha256.Sum256([]byte(CanonicalURL(remote)))
+	return filepath.Join(dir, hex.EncodeToString(hash[:])+".json"), nil
+}
+
+// ReadSnapshot returns the snapshot for the provided remote. If no
+// snapshot exists, [ErrNoSnapshot] is returned. Only
+// [ListRemoteOptions.CacheDir] is used from opts, which may be nil.
+func ReadSnapshot(remote string, opts *ListRemoteOptions) (*Snapshot, error) {
+	if opts == nil {
+		opts = &ListRemoteOptions{}
+	}
+
+	path, err := snapshotPath(remote, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s", ErrNoSnapshot, remote)
+		}
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	var snap Snapshot
+	if err := json.Unmarshal(b, &snap); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot %s: %w", path, err)
+	}
+
+	return &snap, nil
+}
+
+// writeSnapshot persists the provided output of [ListRemote] for the
+// provided remote.
+func writeSnapshot(remote string, remotes [][]string, opts *ListRemoteOptions) error {
+	path, err := snapshotPath(remote, opts)
+	if err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(&Snapshot{
+		URL:       CanonicalURL(remote),
+		FetchedAt: time.Now().UTC(),
+		Remotes:   remotes,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+
+	// Write to a temporary file first to ensure readers never see a
+	// partially written snapshot.
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".snapshot-*")
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 	"testing"
 
 	"github.com/jaredallard/vcs/git"
+	"github.com/jaredallard/vcs/internal/testutil"
 	"gotest.tools/v3/assert"
 )
 
@@ -52,3 +53,152 @@ func TestGit(t *testing.T) {
 		assert.ErrorContains(t, err, "no such file or directory")
 	})
 }
+
+func TestListTagsOnBranch(t *testing.T) {
+	ctx := context.Background()
+
+	repo := testutil.NewLocalRepo(t,
+		[]string{"commit", "--allow-empty", "-m", "initial"},
+		[]string{"tag", "v1.0.0"},
+		[]string{"checkout", "-b", "release-1"},
+		[]string{"commit", "--allow-empty", "-m", "fix"},
+		[]string{"tag", "v1.0.1"},
+		[]string{"checkout", "main"},
+		[]string{"commit", "--allow-empty", "-m", "feature"},
+		[]string{"tag", "v2.0.0"},
+	)
+
+	tags, err := git.ListTagsOnBranch(ctx, repo, "release-1")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, tags, []string{"v1.0.0", "v1.0.1"})
+}
+
+func TestRemoteCapabilities(t *testing.T) {
+	ctx := context.Background()
+
+	repo := testutil.NewLocalRepo(t, []string{"commit", "--allow-empty", "-m", "initial"})
+
+	caps, err := git.RemoteCapabilities(ctx, repo)
+	assert.NilError(t, err)
+	assert.Equal(t, caps.ProtocolVersion, 2)
+	assert.Assert(t, caps.Has("ls-refs"), "expected ls-refs capability, got %v", caps.Capabilities)
+	assert.Assert(t, caps.Agent() != "", "expected an agent to be advertised")
+	assert.Equal(t, caps.Symrefs["HEAD"], "refs/heads/main")
+}
+
+func TestListRemoteSnapshots(t *testing.T) {
+	ctx := context.Background()
+
+	repo := testutil.NewLocalRepo(t,
+		[]string{"commit", "--allow-empty", "-m", "initial"},
+		[]string{"tag", "v1.0.0"},
+	)
+	cacheDir := t.TempDir()
+
+	_, err := git.ListRemote(ctx, repo, &git.ListRemoteOptions{Offline: true, CacheDir: cacheDir})
+	assert.ErrorIs(t, err, git.ErrNoSnapshot)
+
+	// Failing to persist a snapshot doesn't fail listing.
+	notADir := filepath.Join(t.TempDir(), "file")
+	assert.NilError(t, os.WriteFile(notADir, nil, 0o600))
+	_, err = git.ListRemote(ctx, repo, &git.ListRemoteOptions{Snapshot: true, CacheDir: notADir})
+	assert.NilError(t, err)
+
+	remotes, err := git.ListRemote(ctx, repo, &git.ListRemoteOptions{Snapshot: true, CacheDir: cacheDir})
+	assert.NilError(t, err)
+
+	snap, err := git.ReadSnapshot(repo, &git.ListRemoteOptions{CacheDir: cacheDir})
+	assert.NilError(t, err)
+	assert.Equal(t, snap.URL, git.CanonicalURL(repo))
+	assert.Assert(t, !snap.FetchedAt.IsZero(), "expected snapshot to have a timestamp")
+
+	// Make the remote unavailable.
+	assert.NilError(t, os.RemoveAll(filepath.Join(repo, ".git")))
+
+	_, err = git.ListRemote(ctx, repo, &git.ListRemoteOptions{CacheDir: cacheDir})
+	assert.ErrorContains(t, err, "failed to get remote branches")
+
+	got, err := git.ListRemote(ctx, repo, &git.ListRemoteOptions{Offline: true, CacheDir: cacheDir})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, got, remotes)
+
+	got, err = git.ListRemote(ctx, repo, &git.ListRemoteOptions{FallbackToSnapshot: true, CacheDir: cacheDir})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, got, remotes)
+}
+
+func TestListRemotes(t *testing.T) {
+	ctx := context.Background()
+	t.Setenv("VCS_CACHE_DIR", t.TempDir())
+
+	remotes := make([]string, 0)
+	for _, tag := range []string{"v1.0.0", "v2.0.0", "v3.0.0"} {
+		remotes = append(remotes, testutil.NewLocalRepo(t,
+			[]string{"commit", "--allow-empty", "-m", "initial"},
+			[]string{"tag", tag},
+		))
+	}
+	remotes = append(remotes, t.TempDir()) // not a git repository
+
+	results, err := git.ListRemotes(ctx, remotes, 2, &git.ListRemoteOptions{ReuseConnections: true})
+	assert.NilError(t, err)
+	assert.Equal(t, len(results), len(remotes))
+
+	for i, res := range results {
+		assert.Equal(t, res.Remote, remotes[i])
+		if i == len(remotes)-1 {
+			assert.ErrorContains(t, res.Err, "failed to get remote branches")
+			continue
+		}
+
+		assert.NilError(t, res.Err)
+		want, err := git.ListRemote(ctx, remotes[i])
+		assert.NilError(t, err)
+		assert.DeepEqual(t, res.Remotes, want)
+	}
+}
+
+func TestCanonicalURL(t *testing.T) {
+	for _, remote := range []string{
+		"https://github.com/jaredallard/vcs",
+		"https://github.com/jaredallard/vcs.git",
+		"git@github.com:jaredallard/vcs.git",
+		"ssh://git@GitHub.com/jaredallard/vcs/",
+		"github.com/jaredallard/vcs",
+	} {
+		assert.Equal(t, git.CanonicalURL(remote), "github.com/jaredallard/vcs", remote)
+	}
+
+	assert.Equal(t, git.CanonicalURL("https://gitlab.example.com:8443/group/repo.git"), "gitlab.example.com:8443/group/repo")
+	assert.Equal(t, git.CanonicalURL("git@[::1]:group/repo.git"), "[::1]/group/repo")
+
+	dir := t.TempDir()
+	assert.Equal(t, git.CanonicalURL(dir), dir)
+}
+
+func TestGetDefaultBranchWithoutOrigin(t *testing.T) {
+	ctx := context.Background()
+
+	upstream := testutil.NewLocalRepo(t, []string{"commit", "--allow-empty", "-m", "initial"})
+	fork := testutil.NewLocalRepo(t,
+		[]string{"remote", "add", "fork", t.TempDir()},
+		[]string{"remote", "add", "upstream", upstream},
+		[]string{"fetch", "upstream"},
+		[]string{"checkout", "-b", "feature", "--track", "upstream/main"},
+	)
+
+	remote, err := git.PrimaryRemote(ctx, fork)
+	assert.NilError(t, err)
+	assert.Equal(t, remote, "upstream")
+
+	branch, err := git.GetDefaultBranch(ctx, fork)
+	assert.NilError(t, err)
+	assert.Equal(t, branch, "main")
+
+	branch, err = git.GetDefaultBranch(ctx, fork, &git.GetDefaultBranchOptions{Remote: "upstream"})
+	assert.NilError(t, err)
+	assert.Equal(t, branch, "main")
+
+	_, err = git.PrimaryRemote(ctx, upstream)
+	assert.ErrorIs(t, err, git.ErrNoRemotes)
+}
@@ -5,6 +5,7 @@ go 1.23.4
 require (
 	github.com/Masterminds/semver/v3 v3.3.1
 	github.com/chainguard-dev/git-urls v1.0.2
+	github.com/fsnotify/fsnotify v1.10.1
 	github.com/google/go-cmp v0.6.0
 	github.com/google/go-github/v68 v68.0.0
 	github.com/jaredallard/archives v1.0.0
@@ -22,5 +23,6 @@ require (
 	github.com/jamespfennell/xz v0.1.2 // indirect
 	github.com/klauspost/compress v1.17.9 // indirect
 	github.com/ulikunitz/xz v0.5.12 // indirect
+	golang.org/x/sys v0.20.0 // indirect
 	golang.org/x/time v0.8.0 // indirect
 )
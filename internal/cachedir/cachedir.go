@@ -0,0 +1,48 @@
+// Copyright (C) 2024 vcs contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+// Package cachedir provides the location of the on-disk cache directory
+// shared by all packages in this module.
+package cachedir
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// EnvVar is the environment variable that can be used to override the
+// location of the cache directory.
+const EnvVar = "VCS_CACHE_DIR"
+
+// Dir returns the path to the shared cache directory joined with the
+// provided elements. The directory is NOT created. By default, this is
+// "vcs" inside of [os.UserCacheDir], but it can be overridden by
+// setting [EnvVar].
+func Dir(elem ...string) (string, error) {
+	base := os.Getenv(EnvVar)
+	if base == "" {
+		userCacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to determine user cache directory: %w", err)
+		}
+		base = filepath.Join(userCacheDir, "vcs")
+	}
+
+	return filepath.Join(append([]string{base}, elem...)...), nil
+}
@@ -0,0 +1,172 @@
+// Copyright (C) 2024 vcs contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package testutil
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/jaredallard/vcs/internal/fileinfo"
+	"github.com/jaredallard/vcs/releases"
+	"github.com/jaredallard/vcs/token"
+)
+
+// _ ensures that [FakeFetcher] implements [releases.Fetcher].
+var _ releases.Fetcher = &FakeFetcher{}
+
+// _ ensures that [FakeFetcher] implements
+// [releases.PublishOrderedFetcher].
+var _ releases.PublishOrderedFetcher = &FakeFetcher{}
+
+// FakeFetcher is a [releases.Fetcher] that serves releases and assets
+// from memory. The same assets are served for every tag.
+type FakeFetcher struct {
+	// Assets are the assets that can be fetched, keyed by name.
+	Assets map[string][]byte
+
+	// Releases are returned by ListReleases in pages of PerPage.
+	Releases []*releases.Release
+
+	// PerPage is the number of releases per page. Defaults to 100.
+	PerPage int
+
+	// Ordered is returned by ListsByPublishDate. Set it if Releases are
+	// ordered by publish date.
+	Ordered bool
+
+	// PagesFetched is the number of pages returned by ListReleases.
+	PagesFetched int
+
+	// Commits are the commits set by FillCommits, keyed by tag.
+	Commits map[string]string
+
+	// Uploaded contains the contents of all uploaded assets, keyed by
+	// name.
+	Uploaded map[string][]byte
+}
+
+// Fetch implements [releases.Fetcher]. Asset names are matched the same
+// way that the VCS providers match them.
+func (f *FakeFetcher) Fetch(_ context.Context, _ *token.Token, opts *releases.FetchOptions) (io.ReadCloser, fs.FileInfo, error) {
+	validAssets := append([]string{}, opts.AssetNames...)
+	if opts.AssetName != "" {
+		validAssets = append(validAssets, opts.AssetName)
+	}
+
+	names := make([]string, 0, len(f.Assets))
+	for name := range f.Assets {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		for _, assetName := range validAssets {
+			if match, err := filepath.Match(assetName, name); (err == nil && match) || assetName == name {
+				contents := f.Assets[name]
+				fi := fileinfo.New(name, int64(len(contents)), time.Time{}, nil)
+				return io.NopCloser(bytes.NewReader(contents)), fi, nil
+			}
+		}
+	}
+
+	return nil, nil, fmt.Errorf("failed to find asset %v in release %s: %w", validAssets, opts.Tag, releases.ErrAssetNotFound)
+}
+
+// GetReleaseNotes implements [releases.Fetcher].
+func (f *FakeFetcher) GetReleaseNotes(_ context.Context, _ *token.Token, opts *releases.GetReleaseNoteOptions) (string, error) {
+	return "notes for " + opts.Tag, nil
+}
+
+// GetRelease implements [releases.Fetcher]. If the release isn't one of
+// Releases, a release with only the tag set is returned.
+func (f *FakeFetcher) GetRelease(_ context.Context, _ *token.Token, opts *releases.GetReleaseOptions) (*releases.Release, error) {
+	for _, rel := range f.Releases {
+		if rel.Tag == opts.Tag {
+			return rel, nil
+		}
+	}
+
+	return &releases.Release{Tag: opts.Tag}, nil
+}
+
+// ListReleases implements [releases.Fetcher].
+func (f *FakeFetcher) ListReleases(
+	_ context.Context, _ *token.Token, _ *releases.ListOptions, page int,
+) ([]*releases.Release, int, error) {
+	perPage := f.PerPage
+	if perPage <= 0 {
+		perPage = 100
+	}
+
+	f.PagesFetched++
+	start := min((page-1)*perPage, len(f.Releases))
+	end := min(start+perPage, len(f.Releases))
+
+	nextPage := 0
+	if end < len(f.Releases) {
+		nextPage = page + 1
+	}
+
+	// Return copies to ensure callers don't rely on our slice, or modify
+	// our releases.
+	rels := make([]*releases.Release, 0, end-start)
+	for _, rel := range f.Releases[start:end] {
+		rel := *rel
+		rels = append(rels, &rel)
+	}
+	return rels, nextPage, nil
+}
+
+// ListsByPublishDate implements [releases.PublishOrderedFetcher].
+func (f *FakeFetcher) ListsByPublishDate() bool {
+	return f.Ordered
+}
+
+// FillCommits implements [releases.Fetcher].
+func (f *FakeFetcher) FillCommits(_ context.Context, _ *token.Token, _ *releases.ListOptions, rels []*releases.Release) error {
+	for _, rel := range rels {
+		if rel.Commit == "" {
+			rel.Commit = f.Commits[rel.Tag]
+		}
+	}
+	return nil
+}
+
+// UploadAssets implements [releases.Fetcher].
+func (f *FakeFetcher) UploadAssets(_ context.Context, _ *token.Token, _ *releases.UploadOptions, files []*os.File) error {
+	if f.Uploaded == nil {
+		f.Uploaded = make(map[string][]byte)
+	}
+
+	for _, file := range files {
+		b, err := io.ReadAll(file)
+		if err != nil {
+			return err
+		}
+		f.Uploaded[filepath.Base(file.Name())] = b
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+// Copyright (C) 2024 vcs contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package releases
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// ChecksumsFileName is the conventional name of the file containing
+// the checksums of all assets in a release.
+const ChecksumsFileName = "checksums.txt"
+
+// ErrChecksumMismatch is returned when the checksum of an asset does
+// not match the expected checksum.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+// Checksums is a map of asset names to their hex encoded SHA-256
+// checksum. It can be written to, and parsed from, the "checksums.txt"
+// format used by tools like goreleaser:
+//
+//	<sha256>  <name>
+type Checksums map[string]string
+
+// Add computes the checksum of the contents of r and stores it as the
+// checksum for the provided asset name.
+func (c Checksums) Add(name string, r io.Reader) error {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return fmt.Errorf("failed to compute checksum for %s: %w", name, err)
+	}
+
+	c[name] = hex.EncodeToString(h.Sum(nil))
+	return nil
+}
+
+// Verify computes the checksum of the contents of r and compares it to
+// the checksum stored for the provided asset name. If no checksum is
+// stored, or the checksums do not match, an error is returned.
+func (c Checksums) Verify(name string, r io.Reader) error {
+	want, ok := c[name]
+	if !ok {
+		return fmt.Errorf("no checksum found for %s", name)
+	}
+
+	got := make(Checksums, 1)
+	if err := got.Add(name, r); err != nil {
+		return err
+	}
+
+	if !strings.EqualFold(got[name], want) {
+		return fmt.Errorf("%w for %s: expected %s, got %s", ErrChecksumMismatch, name, want, got[name])
+	}
+
+	return nil
+}
+
+// WriteTo writes the checksums to w in the "checksums.txt" format,
+// sorted by asset name.
+func (c Checksums) WriteTo(w io.Writer) (int64, error) {
+	names := make([]string, 0, len(c))
+	for name := range c {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var total int64
+	for _, name := range names {
+		n, err := fmt.Fprintf(w, "%s  %s\n", c[name], name)
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+	}
+
+	return total, nil
+}
+
+// ParseChecksums parses a "checksums.txt" file from r.
+func ParseChecksums(r io.Reader) (Checksums, error) {
+	c := make(Checksums)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		// Names may contain whitespace, so only split on the first run of
+		// it.
+		i := strings.IndexFunc(line, unicode.IsSpace)
+		if i == -1 {
+			return nil, fmt.Errorf("invalid checksum line: %q", line)
+		}
+		sum, name := line[:i], strings.TrimLeftFunc(line[i:], unicode.IsSpace)
+
+		// sha256sum prefixes names with a '*' when in binary mode.
+		c[strings.TrimPrefix(name, "*")] = sum
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
@@ -0,0 +1,371 @@
+package releases_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jaredallard/vcs/internal/testutil"
+	"github.com/jaredallard/vcs/releases"
+	"github.com/jaredallard/vcs/token"
+)
+
+func TestFetcherOverride(t *testing.T) {
+	ctx := context.Background()
+	f := &testutil.FakeFetcher{Assets: map[string][]byte{"asset.tar.gz": []byte("asset")}}
+
+	// Unknown providers are supported when a fetcher is provided.
+	rc, fi, err := releases.Fetch(ctx, &releases.FetchOptions{
+		Fetcher:   f,
+		RepoURL:   "https://git.example.com/org/repo",
+		Tag:       "v1.0.0",
+		AssetName: "asset.tar.gz",
+	})
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	defer rc.Close()
+
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("Fetch() read error = %v", err)
+	}
+	if string(b) != "asset" || fi.Name() != "asset.tar.gz" {
+		t.Errorf("Fetch() = %q (%s), want %q (asset.tar.gz)", b, fi.Name(), "asset")
+	}
+
+	notes, err := releases.GetReleaseNotes(ctx, &releases.GetReleaseNoteOptions{
+		Fetcher: f,
+		RepoURL: "https://github.com/jaredallard/vcs",
+		Tag:     "v1.0.0",
+	})
+	if err != nil {
+		t.Fatalf("GetReleaseNotes() error = %v", err)
+	}
+	if notes != "notes for v1.0.0" {
+		t.Errorf("GetReleaseNotes() = %q, want %q", notes, "notes for v1.0.0")
+	}
+
+	rel, err := releases.GetRelease(ctx, &releases.GetReleaseOptions{
+		Fetcher: f,
+		RepoURL: "https://git.example.com/org/repo",
+		Tag:     "v1.0.0",
+	})
+	if err != nil {
+		t.Fatalf("GetRelease() error = %v", err)
+	}
+	if rel.Tag != "v1.0.0" {
+		t.Errorf("GetRelease() tag = %q, want %q", rel.Tag, "v1.0.0")
+	}
+}
+
+// noFileInfoFetcher is a [testutil.FakeFetcher] that doesn't return
+// file information for assets.
+type noFileInfoFetcher struct {
+	*testutil.FakeFetcher
+}
+
+// Fetch implements [releases.Fetcher].
+func (f *noFileInfoFetcher) Fetch(ctx context.Context, t *token.Token, opts *releases.FetchOptions) (io.ReadCloser, fs.FileInfo, error) {
+	rc, _, err := f.FakeFetcher.Fetch(ctx, t, opts)
+	return rc, nil, err
+}
+
+func TestFetchRequiresFileInfo(t *testing.T) {
+	f := &noFileInfoFetcher{&testutil.FakeFetcher{Assets: map[string][]byte{"asset.tar.gz": []byte("asset")}}}
+	_, _, err := releases.Fetch(context.Background(), &releases.FetchOptions{
+		Fetcher:   f,
+		RepoURL:   "https://git.example.com/org/repo",
+		Tag:       "v1.0.0",
+		AssetName: "asset.tar.gz",
+		Inspector: func(fs.FileInfo, io.Reader) error { return nil },
+	})
+	if err == nil {
+		t.Errorf("Fetch() expected error for missing file information")
+	}
+}
+
+func TestUploadRequiresWritePermission(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte(`{"repositories":[{"full_name":"jaredallard/vcs","permissions":{"pull":true,"push":false}}]}`))
+	}))
+	defer srv.Close()
+
+	t.Setenv("GITHUB_ACTIONS", "true")
+	t.Setenv("GITHUB_API_URL", srv.URL)
+	t.Setenv("GITHUB_REPOSITORY", "jaredallard/vcs")
+	t.Setenv("GITHUB_TOKEN", "actions-token")
+
+	// Ensure the token is fetched, and not kept around for other tests.
+	resetCache := func() {
+		if err := token.SetCacheBackend(token.NewMemoryCacheBackend()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	resetCache()
+	t.Cleanup(resetCache)
+
+	asset := filepath.Join(t.TempDir(), "a.tar.gz")
+	if err := os.WriteFile(asset, []byte("a"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	f := &testutil.FakeFetcher{}
+	err := releases.Upload(context.Background(), &releases.UploadOptions{
+		Fetcher: f,
+		RepoURL: "https://github.com/jaredallard/vcs",
+		Tag:     "v1.0.0",
+		Assets:  []string{asset},
+	})
+	if err == nil || !strings.Contains(err.Error(), "missing contents:write permission") {
+		t.Errorf("Upload() error = %v, want missing permission", err)
+	}
+	if len(f.Uploaded) != 0 {
+		t.Errorf("Upload() uploaded %d assets, want none", len(f.Uploaded))
+	}
+}
+
+func TestList(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	// Releases are out of order across pages (of 3) to ensure that they
+	// get sorted globally.
+	f := &testutil.FakeFetcher{PerPage: 3, Releases: []*releases.Release{
+		{Tag: "v1.0.0", PublishedAt: now.Add(-6 * time.Hour)},
+		{Tag: "v1.4.0", PublishedAt: now.Add(-2 * time.Hour)},
+		{Tag: "v1.2.0", PublishedAt: now.Add(-4 * time.Hour)},
+		{Tag: "v1.5.0", PublishedAt: now.Add(-1 * time.Hour)},
+		{Tag: "v1.1.0", PublishedAt: now.Add(-5 * time.Hour)},
+		{Tag: "v1.3.0", PublishedAt: now.Add(-3 * time.Hour)},
+		{Tag: "draft"},
+	}}
+	want := []string{"draft", "v1.5.0", "v1.4.0", "v1.3.0", "v1.2.0", "v1.1.0", "v1.0.0"}
+
+	tags := func(rels []*releases.Release) []string {
+		out := make([]string, 0, len(rels))
+		for _, rel := range rels {
+			out = append(out, rel.Tag)
+		}
+		return out
+	}
+
+	// Without a limit, everything is returned.
+	res, err := releases.List(ctx, &releases.ListOptions{Fetcher: f, RepoURL: "https://git.example.com/org/repo"})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if got := tags(res.Releases); strings.Join(got, ",") != strings.Join(want, ",") || res.Cursor != "" {
+		t.Errorf("List() = %v (cursor %q), want %v", got, res.Cursor, want)
+	}
+
+	// Commits are filled in for the returned releases.
+	f.Commits = map[string]string{"v1.5.0": "abc"}
+	res, err = releases.List(ctx, &releases.ListOptions{Fetcher: f, RepoURL: "https://git.example.com/org/repo", Limit: 2})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if got := res.Releases[1]; got.Tag != "v1.5.0" || got.Commit != "abc" {
+		t.Errorf("List() returned %s with commit %q, want v1.5.0 with commit %q", got.Tag, got.Commit, "abc")
+	}
+
+	// With a limit, every release is returned exactly once, in order,
+	// when following the cursor.
+	for _, limit := range []int{1, 2, 3, 4, 7} {
+		got := make([]string, 0)
+		cursor := ""
+		for range len(want) + 1 {
+			res, err := releases.List(ctx, &releases.ListOptions{
+				Fetcher: f, RepoURL: "https://git.example.com/org/repo", Limit: limit, Cursor: cursor,
+			})
+			if err != nil {
+				t.Fatalf("List(limit=%d) error = %v", limit, err)
+			}
+			if len(res.Releases) > limit {
+				t.Fatalf("List(limit=%d) returned %d releases", limit, len(res.Releases))
+			}
+
+			got = append(got, tags(res.Releases)...)
+			if cursor = res.Cursor; cursor == "" {
+				break
+			}
+		}
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("List(limit=%d) = %v, want %v", limit, got, want)
+		}
+	}
+
+	// Releases published between calls don't cause releases to be
+	// skipped or returned twice.
+	res, err = releases.List(ctx, &releases.ListOptions{Fetcher: f, RepoURL: "https://git.example.com/org/repo", Limit: 2})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	f.Releases = append([]*releases.Release{
+		{Tag: "v1.6.0", PublishedAt: now},
+		{Tag: "v0.9.0", PublishedAt: now.Add(-7 * time.Hour)},
+	}, f.Releases...)
+
+	got := tags(res.Releases)
+	res, err = releases.List(ctx, &releases.ListOptions{Fetcher: f, RepoURL: "https://git.example.com/org/repo", Cursor: res.Cursor})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	got = append(got, tags(res.Releases)...)
+	if want := append(append([]string{}, want...), "v0.9.0"); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("List() = %v, want %v", got, want)
+	}
+
+	if _, err := releases.List(ctx, &releases.ListOptions{Fetcher: f, RepoURL: "https://git.example.com/org/repo", Cursor: "??"}); err == nil {
+		t.Errorf("List() expected error for invalid cursor")
+	}
+}
+
+func TestListStopsPagingWhenOrdered(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	// Releases are ordered by publish date, but releases published at
+	// the same time (v1.4.0-v1.6.0) are split across pages and not
+	// ordered by tag.
+	f := &testutil.FakeFetcher{PerPage: 2, Ordered: true, Releases: []*releases.Release{
+		{Tag: "v1.9.0", PublishedAt: now},
+		{Tag: "v1.8.0", PublishedAt: now.Add(-1 * time.Hour)},
+		{Tag: "v1.5.0", PublishedAt: now.Add(-2 * time.Hour)},
+		{Tag: "v1.4.0", PublishedAt: now.Add(-2 * time.Hour)},
+		{Tag: "v1.6.0", PublishedAt: now.Add(-2 * time.Hour)},
+		{Tag: "v1.3.0", PublishedAt: now.Add(-3 * time.Hour)},
+		{Tag: "v1.2.0", PublishedAt: now.Add(-4 * time.Hour)},
+		{Tag: "v1.1.0", PublishedAt: now.Add(-5 * time.Hour)},
+		{Tag: "v1.0.0", PublishedAt: now.Add(-6 * time.Hour)},
+	}}
+	want := []string{"v1.9.0", "v1.8.0", "v1.6.0", "v1.5.0", "v1.4.0", "v1.3.0", "v1.2.0", "v1.1.0", "v1.0.0"}
+
+	// Only the pages needed to determine the first releases are fetched.
+	_, err := releases.List(ctx, &releases.ListOptions{Fetcher: f, RepoURL: "https://git.example.com/org/repo", Limit: 3})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if f.PagesFetched != 3 {
+		t.Errorf("List() fetched %d pages, want 3", f.PagesFetched)
+	}
+
+	// Following the cursor still returns every release exactly once, in
+	// order.
+	for _, limit := range []int{1, 2, 3, 4} {
+		got := make([]string, 0)
+		cursor := ""
+		for range len(want) + 1 {
+			res, err := releases.List(ctx, &releases.ListOptions{
+				Fetcher: f, RepoURL: "https://git.example.com/org/repo", Limit: limit, Cursor: cursor,
+			})
+			if err != nil {
+				t.Fatalf("List(limit=%d) error = %v", limit, err)
+			}
+
+			for _, rel := range res.Releases {
+				got = append(got, rel.Tag)
+			}
+			if cursor = res.Cursor; cursor == "" {
+				break
+			}
+		}
+		if strings.Join(got, ",") != strings.Join(want, ",") {
+			t.Errorf("List(limit=%d) = %v, want %v", limit, got, want)
+		}
+	}
+}
+
+func TestUpload(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake cosign is a shell script")
+	}
+
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	assets := make([]string, 0)
+	for _, name := range []string{"a.tar.gz", "b.tar.gz"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(name), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		assets = append(assets, path)
+	}
+
+	// cosign writes the signature to the value of --output-signature,
+	// the blob being signed is always the last argument.
+	cosign := filepath.Join(dir, "cosign")
+	script := `#!/bin/sh
+while [ $# -gt 1 ]; do
+  if [ "$1" = "--output-signature" ]; then sig="$2"; fi
+  shift
+done
+echo "signature of $(basename "$1")" > "$sig"
+`
+	if err := os.WriteFile(cosign, []byte(script), 0o700); err != nil {
+		t.Fatal(err)
+	}
+
+	f := &testutil.FakeFetcher{}
+	err := releases.Upload(ctx, &releases.UploadOptions{
+		Fetcher: f,
+		RepoURL: "https://git.example.com/org/repo",
+		Tag:     "v1.0.0",
+		Assets:  assets,
+		Sign:    &releases.SignOptions{Key: "cosign.key", Cosign: cosign},
+	})
+	if err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+
+	names := make([]string, 0, len(f.Uploaded))
+	for name := range f.Uploaded {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	want := []string{"a.tar.gz", "b.tar.gz", "checksums.txt", "checksums.txt.sig"}
+	if !slices.Equal(names, want) {
+		t.Fatalf("Upload() uploaded %v, want %v", names, want)
+	}
+
+	checksums, err := releases.ParseChecksums(bytes.NewReader(f.Uploaded[releases.ChecksumsFileName]))
+	if err != nil {
+		t.Fatalf("ParseChecksums() error = %v", err)
+	}
+	for _, name := range []string{"a.tar.gz", "b.tar.gz"} {
+		if err := checksums.Verify(name, bytes.NewReader(f.Uploaded[name])); err != nil {
+			t.Errorf("Verify() error = %v", err)
+		}
+	}
+
+	if got := string(f.Uploaded["checksums.txt.sig"]); got != "signature of checksums.txt\n" {
+		t.Errorf("Upload() signature = %q", got)
+	}
+
+	// Checksums are generated unless skipped.
+	f = &testutil.FakeFetcher{}
+	err = releases.Upload(ctx, &releases.UploadOptions{
+		Fetcher:       f,
+		RepoURL:       "https://git.example.com/org/repo",
+		Tag:           "v1.0.0",
+		Assets:        assets,
+		SkipChecksums: true,
+	})
+	if err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+	if _, ok := f.Uploaded[releases.ChecksumsFileName]; ok || len(f.Uploaded) != 2 {
+		t.Errorf("Upload() with SkipChecksums uploaded %d assets, want 2", len(f.Uploaded))
+	}
+}
@@ -24,12 +24,13 @@ import (
 	"fmt"
 	"io"
 	"net/http"
-	"net/url"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 
 	gogithub "github.com/google/go-github/v68/github"
+	"github.com/jaredallard/vcs"
 	"github.com/jaredallard/vcs/internal/fileinfo"
 	"github.com/jaredallard/vcs/releases/internal/opts"
 	"github.com/jaredallard/vcs/token"
@@ -58,17 +59,17 @@ func assetToFileInfo(a *gogithub.ReleaseAsset) os.FileInfo {
 //
 // Example: https://github.com/rgst-io/stencil
 func getOrgRepoFromURL(urlStr string) (owner, repo string, err error) {
-	u, err := url.Parse(urlStr)
+	u, err := vcs.ParseRepoURL(urlStr)
 	if err != nil {
 		return "", "", err
 	}
 
-	// /rgst-io/stencil -> ["", "rgst-io", "stencil"]
-	spl := strings.Split(u.Path, "/")
-	if len(spl) != 3 {
+	// rgst-io/stencil -> rgst-io, stencil
+	owner = u.Owner()
+	if owner == "" || strings.Contains(owner, "/") {
 		return "", "", fmt.Errorf("invalid Github URL: %s", urlStr)
 	}
-	return spl[1], spl[2], nil
+	return owner, u.Name(), nil
 }
 
 // createClient creates a Github client
@@ -98,6 +99,152 @@ func (f *Fetcher) GetReleaseNotes(ctx context.Context, t *token.Token, opt *opts
 	return rel.GetBody(), nil
 }
 
+// GetRelease returns information about the release for a given tag
+func (f *Fetcher) GetRelease(ctx context.Context, t *token.Token, opt *opts.GetReleaseOptions) (*opts.Release, error) {
+	gh := f.createClient(ctx, t)
+	friendlyRepo := strings.TrimPrefix(opt.RepoURL, "https://")
+
+	org, repo, err := getOrgRepoFromURL(opt.RepoURL)
+	if err != nil {
+		return nil, err
+	}
+
+	rel, _, err := gh.Repositories.GetReleaseByTag(ctx, org, repo, opt.Tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get release for %s@%s: %w", friendlyRepo, opt.Tag, err)
+	}
+
+	commit, err := getTagCommit(ctx, gh, org, repo, rel.GetTagName())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get commit for %s@%s: %w", friendlyRepo, opt.Tag, err)
+	}
+
+	r := releaseToRelease(rel)
+	r.Commit = commit
+	return r, nil
+}
+
+// ListReleases returns a page of releases, ordered by creation date.
+// Commits are not set, see [Fetcher.FillCommits].
+func (f *Fetcher) ListReleases(ctx context.Context, t *token.Token, opt *opts.ListOptions, page int) ([]*opts.Release, int, error) {
+	gh := f.createClient(ctx, t)
+	friendlyRepo := strings.TrimPrefix(opt.RepoURL, "https://")
+
+	org, repo, err := getOrgRepoFromURL(opt.RepoURL)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rels, resp, err := gh.Repositories.ListReleases(ctx, org, repo, &gogithub.ListOptions{Page: page, PerPage: 100})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list releases for %s: %w", friendlyRepo, err)
+	}
+
+	releases := make([]*opts.Release, 0, len(rels))
+	for _, rel := range rels {
+		releases = append(releases, releaseToRelease(rel))
+	}
+
+	return releases, resp.NextPage, nil
+}
+
+// FillCommits sets the commits of the provided releases by listing the
+// tags of the repository. Annotated tags require an additional request
+// each to be peeled.
+func (f *Fetcher) FillCommits(ctx context.Context, t *token.Token, opt *opts.ListOptions, releases []*opts.Release) error {
+	if !slices.ContainsFunc(releases, func(r *opts.Release) bool { return r.Commit == "" }) {
+		return nil
+	}
+
+	gh := f.createClient(ctx, t)
+	friendlyRepo := strings.TrimPrefix(opt.RepoURL, "https://")
+
+	org, repo, err := getOrgRepoFromURL(opt.RepoURL)
+	if err != nil {
+		return err
+	}
+
+	tags, err := listTags(ctx, gh, org, repo)
+	if err != nil {
+		return fmt.Errorf("failed to list tags for %s: %w", friendlyRepo, err)
+	}
+
+	for _, r := range releases {
+		// Draft releases may not have a tag yet.
+		obj, ok := tags[r.Tag]
+		if r.Commit != "" || !ok {
+			continue
+		}
+
+		r.Commit, err = peelTag(ctx, gh, org, repo, obj)
+		if err != nil {
+			return fmt.Errorf("failed to get commit for %s@%s: %w", friendlyRepo, r.Tag, err)
+		}
+	}
+
+	return nil
+}
+
+// listTags returns the objects that all tags in the provided repository
+// point to, keyed by tag name.
+func listTags(ctx context.Context, gh *gogithub.Client, org, repo string) (map[string]*gogithub.GitObject, error) {
+	tags := make(map[string]*gogithub.GitObject)
+	listOpts := &gogithub.ReferenceListOptions{Ref: "tags/", ListOptions: gogithub.ListOptions{PerPage: 100}}
+	for {
+		refs, resp, err := gh.Git.ListMatchingRefs(ctx, org, repo, listOpts)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, ref := range refs {
+			tags[strings.TrimPrefix(ref.GetRef(), "refs/tags/")] = ref.GetObject()
+		}
+
+		if resp.NextPage == 0 {
+			return tags, nil
+		}
+		listOpts.Page = resp.NextPage
+	}
+}
+
+// releaseToRelease converts a [gogithub.RepositoryRelease] into an
+// [opts.Release]. The commit is not set.
+func releaseToRelease(rel *gogithub.RepositoryRelease) *opts.Release {
+	return &opts.Release{
+		Tag:         rel.GetTagName(),
+		Name:        rel.GetName(),
+		Notes:       rel.GetBody(),
+		Author:      rel.GetAuthor().GetLogin(),
+		CreatedAt:   rel.GetCreatedAt().Time,
+		PublishedAt: rel.GetPublishedAt().Time,
+	}
+}
+
+// getTagCommit returns the SHA of the commit that the provided tag
+// points to, peeling annotated tags.
+func getTagCommit(ctx context.Context, gh *gogithub.Client, org, repo, tag string) (string, error) {
+	ref, _, err := gh.Git.GetRef(ctx, org, repo, "tags/"+tag)
+	if err != nil {
+		return "", err
+	}
+
+	return peelTag(ctx, gh, org, repo, ref.GetObject())
+}
+
+// peelTag returns the SHA of the commit that the provided object, as
+// pointed to by a tag, resolves to. Annotated tags are peeled.
+func peelTag(ctx context.Context, gh *gogithub.Client, org, repo string, obj *gogithub.GitObject) (string, error) {
+	for obj.GetType() == "tag" {
+		t, _, err := gh.Git.GetTag(ctx, org, repo, obj.GetSHA())
+		if err != nil {
+			return "", err
+		}
+		obj = t.GetObject()
+	}
+
+	return obj.GetSHA(), nil
+}
+
 // Fetch fetches a release from a github repository and the underlying
 // release asset.
 func (f *Fetcher) Fetch(ctx context.Context, t *token.Token, opt *opts.FetchOptions) (io.ReadCloser, os.FileInfo, error) {
@@ -144,7 +291,7 @@ func (f *Fetcher) Fetch(ctx context.Context, t *token.Token, opt *opts.FetchOpti
 	}
 	if a == nil {
 		return nil, nil,
-			fmt.Errorf("failed to find asset %v in release %s@%s", validAssets, friendlyRepo, opt.Tag)
+			fmt.Errorf("failed to find asset %v in release %s@%s: %w", validAssets, friendlyRepo, opt.Tag, opts.ErrAssetNotFound)
 	}
 
 	// The second return value is a redirectURL, but by passing
@@ -157,3 +304,29 @@ func (f *Fetcher) Fetch(ctx context.Context, t *token.Token, opt *opts.FetchOpti
 
 	return rc, assetToFileInfo(a), nil
 }
+
+// UploadAssets uploads files as assets to an existing release.
+func (f *Fetcher) UploadAssets(ctx context.Context, t *token.Token, opt *opts.UploadOptions, files []*os.File) error {
+	gh := f.createClient(ctx, t)
+	friendlyRepo := strings.TrimPrefix(opt.RepoURL, "https://")
+
+	org, repo, err := getOrgRepoFromURL(opt.RepoURL)
+	if err != nil {
+		return err
+	}
+
+	rel, _, err := gh.Repositories.GetReleaseByTag(ctx, org, repo, opt.Tag)
+	if err != nil {
+		return fmt.Errorf("failed to get release for %s@%s: %w", friendlyRepo, opt.Tag, err)
+	}
+
+	for _, file := range files {
+		name := filepath.Base(file.Name())
+		_, _, err := gh.Repositories.UploadReleaseAsset(ctx, org, repo, rel.GetID(), &gogithub.UploadOptions{Name: name}, file)
+		if err != nil {
+			return fmt.Errorf("failed to upload asset %s to release %s@%s: %w", name, friendlyRepo, opt.Tag, err)
+		}
+	}
+
+	return nil
+}
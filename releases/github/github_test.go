@@ -0,0 +1,33 @@
+package github
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+// TestGetOrgRepoFromURL ensures that every kind of Github URL that is
+// detected as Github can be turned into an owner and repository.
+func TestGetOrgRepoFromURL(t *testing.T) {
+	for _, repoURL := range []string{
+		"https://github.com/rgst-io/stencil",
+		"https://github.com/rgst-io/stencil.git",
+		"https://github.com/rgst-io/stencil/",
+		"git@github.com:rgst-io/stencil.git",
+		"ssh://git@github.com/rgst-io/stencil",
+		"github.com/rgst-io/stencil",
+	} {
+		owner, repo, err := getOrgRepoFromURL(repoURL)
+		assert.NilError(t, err, repoURL)
+		assert.Equal(t, owner, "rgst-io", repoURL)
+		assert.Equal(t, repo, "stencil", repoURL)
+	}
+
+	for _, repoURL := range []string{
+		"https://github.com/rgst-io",
+		"https://github.com/rgst-io/stencil/tree/main",
+	} {
+		_, _, err := getOrgRepoFromURL(repoURL)
+		assert.ErrorContains(t, err, "invalid Github URL", repoURL)
+	}
+}
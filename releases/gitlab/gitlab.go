@@ -23,13 +23,14 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
-	"net/url"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
+	"github.com/jaredallard/vcs"
 	"github.com/jaredallard/vcs/internal/fileinfo"
 	"github.com/jaredallard/vcs/releases/internal/opts"
 	"github.com/jaredallard/vcs/token"
@@ -40,6 +41,10 @@ import (
 // [opts.Fetcher] interface.
 var _ opts.Fetcher = &Fetcher{}
 
+// _ is a compile-time assertion that Fetcher implements the
+// [opts.PublishOrderedFetcher] interface.
+var _ opts.PublishOrderedFetcher = &Fetcher{}
+
 // Fetcher implements the [releases.Fetcher] interface for Gitlab releases.
 type Fetcher struct{}
 
@@ -49,19 +54,51 @@ func assetToFileInfo(rl *gogitlab.ReleaseLink) os.FileInfo {
 	return fileinfo.New(rl.Name, 0, time.Time{}, rl)
 }
 
-// createClient creates a Gitlab client
-func (f *Fetcher) createClient(t *token.Token) (*gogitlab.Client, error) {
+// apiBaseURL returns the base URL of the Gitlab API for the provided
+// repository URL, or an empty string for gitlab.com. Ports are only
+// kept for HTTP(S) URLs, since SSH ports aren't used by the API.
+func apiBaseURL(u *vcs.RepoURL) string {
+	if u.Host == "gitlab.com" {
+		return ""
+	}
+
+	scheme, host := "https", u.Host
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if u.Scheme == "http" || u.Scheme == "https" {
+		scheme = u.Scheme
+		if u.Port != "" {
+			host = net.JoinHostPort(u.Host, u.Port)
+		}
+	}
+
+	return scheme + "://" + host + "/api/v4"
+}
+
+// createClient creates a Gitlab client for the instance hosting the
+// provided repository.
+func (f *Fetcher) createClient(t *token.Token, repoURL string) (*gogitlab.Client, error) {
+	u, err := vcs.ParseRepoURL(repoURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var clientOpts []gogitlab.ClientOptionFunc
+	if baseURL := apiBaseURL(u); baseURL != "" {
+		clientOpts = append(clientOpts, gogitlab.WithBaseURL(baseURL))
+	}
+
 	if t.IsUnauthenticated() {
-		return gogitlab.NewClient("")
+		return gogitlab.NewClient("", clientOpts...)
 	}
 
 	var client *gogitlab.Client
-	var err error
 	switch t.Type {
 	case "pat", "": // Default is PAT.
-		client, err = gogitlab.NewClient(t.Value)
+		client, err = gogitlab.NewClient(t.Value, clientOpts...)
 	case "job":
-		client, err = gogitlab.NewJobClient(t.Value)
+		client, err = gogitlab.NewJobClient(t.Value, clientOpts...)
 	default:
 		return nil, fmt.Errorf("unknown token type %s", t.Type)
 	}
@@ -70,12 +107,12 @@ func (f *Fetcher) createClient(t *token.Token) (*gogitlab.Client, error) {
 
 // getPIDFromRepoURL returns the project ID from a given repository URL.
 func (f *Fetcher) getPIDFromRepoURL(repoURL string, glab *gogitlab.Client) (int, error) {
-	u, err := url.Parse(repoURL)
+	u, err := vcs.ParseRepoURL(repoURL)
 	if err != nil {
 		return 0, err
 	}
 
-	proj, _, err := glab.Projects.GetProject(strings.TrimPrefix(u.Path, "/"), nil)
+	proj, _, err := glab.Projects.GetProject(u.Path, nil)
 	if err != nil {
 		return 0, err
 	}
@@ -85,7 +122,7 @@ func (f *Fetcher) getPIDFromRepoURL(repoURL string, glab *gogitlab.Client) (int,
 
 // GetReleaseNotes returns the release notes for a given tag
 func (f *Fetcher) GetReleaseNotes(_ context.Context, t *token.Token, opt *opts.GetReleaseNoteOptions) (string, error) {
-	glab, err := f.createClient(t)
+	glab, err := f.createClient(t, opt.RepoURL)
 	if err != nil {
 		return "", err
 	}
@@ -103,10 +140,95 @@ func (f *Fetcher) GetReleaseNotes(_ context.Context, t *token.Token, opt *opts.G
 	return rel.Description, nil
 }
 
+// GetRelease returns information about the release for a given tag
+func (f *Fetcher) GetRelease(_ context.Context, t *token.Token, opt *opts.GetReleaseOptions) (*opts.Release, error) {
+	glab, err := f.createClient(t, opt.RepoURL)
+	if err != nil {
+		return nil, err
+	}
+
+	friendlyRepo := strings.TrimPrefix(opt.RepoURL, "https://")
+	pid, err := f.getPIDFromRepoURL(opt.RepoURL, glab)
+	if err != nil {
+		return nil, err
+	}
+
+	rel, _, err := glab.Releases.GetRelease(pid, opt.Tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get release for %s@%s: %w", friendlyRepo, opt.Tag, err)
+	}
+
+	return releaseToRelease(rel), nil
+}
+
+// ListReleases returns a page of releases, ordered by release date.
+func (f *Fetcher) ListReleases(_ context.Context, t *token.Token, opt *opts.ListOptions, page int) ([]*opts.Release, int, error) {
+	glab, err := f.createClient(t, opt.RepoURL)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	friendlyRepo := strings.TrimPrefix(opt.RepoURL, "https://")
+	pid, err := f.getPIDFromRepoURL(opt.RepoURL, glab)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rels, resp, err := glab.Releases.ListReleases(pid, &gogitlab.ListReleasesOptions{
+		ListOptions: gogitlab.ListOptions{Page: page, PerPage: 100},
+		OrderBy:     gogitlab.Ptr("released_at"),
+		Sort:        gogitlab.Ptr("desc"),
+	})
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list releases for %s: %w", friendlyRepo, err)
+	}
+
+	releases := make([]*opts.Release, 0, len(rels))
+	for _, rel := range rels {
+		releases = append(releases, releaseToRelease(rel))
+	}
+
+	return releases, resp.NextPage, nil
+}
+
+// ListsByPublishDate implements [opts.PublishOrderedFetcher].
+// ListReleases orders releases by their release date.
+func (f *Fetcher) ListsByPublishDate() bool {
+	return true
+}
+
+// FillCommits implements [opts.Fetcher]. Commits are always set by
+// ListReleases, so this does nothing.
+func (f *Fetcher) FillCommits(context.Context, *token.Token, *opts.ListOptions, []*opts.Release) error {
+	return nil
+}
+
+// releaseToRelease converts a [gogitlab.Release] into an
+// [opts.Release].
+func releaseToRelease(rel *gogitlab.Release) *opts.Release {
+	return &opts.Release{
+		Tag:         rel.TagName,
+		Name:        rel.Name,
+		Notes:       rel.Description,
+		Author:      rel.Author.Username,
+		CreatedAt:   ptrTime(rel.CreatedAt),
+		PublishedAt: ptrTime(rel.ReleasedAt),
+		Commit:      rel.Commit.ID,
+	}
+}
+
+// ptrTime returns the value of t, or the zero time if t is nil.
+func ptrTime(t *time.Time) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return *t
+}
+
 // Fetch fetches a release from a github repository and the underlying
 // release asset.
 func (f *Fetcher) Fetch(_ context.Context, t *token.Token, opt *opts.FetchOptions) (io.ReadCloser, os.FileInfo, error) {
-	glab, err := f.createClient(t)
+	glab, err := f.createClient(t, opt.RepoURL)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -151,7 +273,7 @@ func (f *Fetcher) Fetch(_ context.Context, t *token.Token, opt *opts.FetchOption
 	}
 	if rl == nil {
 		return nil, nil,
-			fmt.Errorf("failed to find asset %v in release %s@%s", validAssets, friendlyRepo, opt.Tag)
+			fmt.Errorf("failed to find asset %v in release %s@%s: %w", validAssets, friendlyRepo, opt.Tag, opts.ErrAssetNotFound)
 	}
 
 	// Download the asset
@@ -170,3 +292,59 @@ func (f *Fetcher) Fetch(_ context.Context, t *token.Token, opt *opts.FetchOption
 	}
 	return resp.Body, assetToFileInfo(rl), nil
 }
+
+// UploadAssets uploads files to the generic package registry of the
+// project and links them to an existing release as assets. The package
+// is named after the project and versioned with the tag of the release.
+func (f *Fetcher) UploadAssets(_ context.Context, t *token.Token, opt *opts.UploadOptions, files []*os.File) error {
+	glab, err := f.createClient(t, opt.RepoURL)
+	if err != nil {
+		return err
+	}
+
+	friendlyRepo := strings.TrimPrefix(opt.RepoURL, "https://")
+	pid, err := f.getPIDFromRepoURL(opt.RepoURL, glab)
+	if err != nil {
+		return err
+	}
+
+	u, err := vcs.ParseRepoURL(opt.RepoURL)
+	if err != nil {
+		return err
+	}
+
+	packageName := u.Name()
+	for _, file := range files {
+		if err := uploadAsset(glab, pid, packageName, friendlyRepo, opt.Tag, file); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// uploadAsset publishes file to the generic package registry and links
+// it to the release for tag.
+func uploadAsset(glab *gogitlab.Client, pid int, packageName, friendlyRepo, tag string, file *os.File) error {
+	name := filepath.Base(file.Name())
+
+	if _, _, err := glab.GenericPackages.PublishPackageFile(pid, packageName, tag, name, file, nil); err != nil {
+		return fmt.Errorf("failed to publish %s to package registry of %s: %w", name, friendlyRepo, err)
+	}
+
+	packageURL, err := glab.GenericPackages.FormatPackageURL(pid, packageName, tag, name)
+	if err != nil {
+		return err
+	}
+
+	_, _, err = glab.ReleaseLinks.CreateReleaseLink(pid, tag, &gogitlab.CreateReleaseLinkOptions{
+		Name:     gogitlab.Ptr(name),
+		URL:      gogitlab.Ptr(glab.BaseURL().String() + packageURL),
+		LinkType: gogitlab.Ptr(gogitlab.PackageLinkType),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to link %s to release %s@%s: %w", name, friendlyRepo, tag, err)
+	}
+
+	return nil
+}
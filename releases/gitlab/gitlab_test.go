@@ -0,0 +1,31 @@
+package gitlab
+
+import (
+	"testing"
+
+	"github.com/jaredallard/vcs"
+	"gotest.tools/v3/assert"
+)
+
+// TestAPIBaseURL ensures that self-hosted Gitlab instances are sent to
+// their own API instead of gitlab.com.
+func TestAPIBaseURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://gitlab.com/group/subgroup/repo", ""},
+		{"git@gitlab.com:group/repo.git", ""},
+		{"https://code.gitlab.corp/group/repo", "https://code.gitlab.corp/api/v4"},
+		{"http://gitlab.example.com:8080/group/repo", "http://gitlab.example.com:8080/api/v4"},
+		{"git@gitlab.example.com:group/repo.git", "https://gitlab.example.com/api/v4"},
+		{"ssh://git@gitlab.example.com:2222/group/repo", "https://gitlab.example.com/api/v4"},
+		{"https://[::1]:8443/group/repo", "https://[::1]:8443/api/v4"},
+		{"git@[::1]:group/repo.git", "https://[::1]/api/v4"},
+	}
+	for _, tt := range tests {
+		u, err := vcs.ParseRepoURL(tt.url)
+		assert.NilError(t, err, tt.url)
+		assert.Equal(t, apiBaseURL(u), tt.want, tt.url)
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright (C) 2024 vcs contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package releases
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+)
+
+// errInspectorDone is used to signal that an inspector has returned
+// successfully without reading the entire asset.
+var errInspectorDone = errors.New("inspector done")
+
+// inspect downloads the asset from rc into a temporary file while
+// streaming it through the provided inspector. If the inspector returns
+// an error, the download is aborted and the error is returned. Errors
+// reading from rc are returned as download failures, even if the
+// inspector returned them. rc is always closed.
+//
+// The returned io.ReadCloser reads from the temporary file, which is
+// removed when closed.
+func inspect(rc io.ReadCloser, fi fs.FileInfo, inspector func(fs.FileInfo, io.Reader) error) (io.ReadCloser, error) {
+	defer rc.Close()
+
+	tmp, err := os.CreateTemp("", "vcs-release-asset-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	cleanup := func() {
+		tmp.Close()
+		os.Remove(tmp.Name())
+	}
+
+	pr, pw := io.Pipe()
+	inspectErrCh := make(chan error, 1)
+	go func() {
+		err := inspector(fi, pr)
+		if err == nil {
+			// Allow the download to continue if we returned before reading
+			// the entire asset.
+			err = errInspectorDone
+		}
+		pr.CloseWithError(err)
+
+		if errors.Is(err, errInspectorDone) {
+			err = nil
+		}
+		inspectErrCh <- err
+	}()
+
+	r := &errReader{r: rc}
+	_, copyErr := io.Copy(io.MultiWriter(tmp, &inspectorWriter{pw}), r)
+	pw.CloseWithError(copyErr)
+
+	inspectErr := <-inspectErrCh
+	if r.err != nil {
+		cleanup()
+		return nil, fmt.Errorf("failed to download asset %s: %w", fi.Name(), r.err)
+	}
+	if err := inspectErr; err != nil {
+		cleanup()
+		return nil, fmt.Errorf("asset %s was rejected by inspector: %w", fi.Name(), err)
+	}
+	if copyErr != nil {
+		cleanup()
+		return nil, fmt.Errorf("failed to download asset %s: %w", fi.Name(), copyErr)
+	}
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		cleanup()
+		return nil, fmt.Errorf("failed to seek temporary file: %w", err)
+	}
+
+	return &tempFile{tmp}, nil
+}
+
+// errReader is an [io.Reader] that records the first error, other than
+// [io.EOF], returned by the underlying reader.
+type errReader struct {
+	r   io.Reader
+	err error
+}
+
+// Read implements [io.Reader].
+func (r *errReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	if err != nil && !errors.Is(err, io.EOF) && r.err == nil {
+		r.err = err
+	}
+	return n, err
+}
+
+// inspectorWriter writes to the inspector's pipe, ignoring writes
+// after the inspector has returned successfully.
+type inspectorWriter struct {
+	pw *io.PipeWriter
+}
+
+// Write implements [io.Writer].
+func (w *inspectorWriter) Write(p []byte) (int, error) {
+	n, err := w.pw.Write(p)
+	if errors.Is(err, errInspectorDone) {
+		return len(p), nil
+	}
+	return n, err
+}
+
+// tempFile is an [os.File] that is removed when closed.
+type tempFile struct {
+	*os.File
+}
+
+// Close closes and removes the underlying file.
+func (f *tempFile) Close() error {
+	err := f.File.Close()
+	if rerr := os.Remove(f.Name()); rerr != nil && err == nil {
+		err = rerr
+	}
+	return err
+}
@@ -22,27 +22,99 @@ package opts
 
 import (
 	"context"
+	"errors"
 	"io"
+	"io/fs"
 	"os"
+	"time"
 
 	"github.com/jaredallard/vcs"
 	"github.com/jaredallard/vcs/token"
 )
 
+// ErrAssetNotFound is returned by [Fetcher.Fetch] when none of the
+// requested assets exist in the release.
+var ErrAssetNotFound = errors.New("asset not found")
+
 // Fetcher is an interface that fetches assets from a release. VCS
 // providers must implement this interface.
 type Fetcher interface {
-	// Fetch returns an asset as a io.ReadCloser
+	// Fetch returns an asset as a io.ReadCloser. If none of the
+	// requested assets exist, an error wrapping [ErrAssetNotFound] must
+	// be returned.
 	Fetch(ctx context.Context, token *token.Token, opts *FetchOptions) (io.ReadCloser, os.FileInfo, error)
 
 	// GetReleaseNotes returns the release notes of a release
 	GetReleaseNotes(ctx context.Context, token *token.Token, opts *GetReleaseNoteOptions) (string, error)
+
+	// GetRelease returns information about a release
+	GetRelease(ctx context.Context, token *token.Token, opts *GetReleaseOptions) (*Release, error)
+
+	// ListReleases returns a single page (1-indexed) of releases,
+	// ordered by date (newest first), along with the next page to fetch
+	// or 0 if there are no more pages. The commits of the releases may
+	// be left empty if determining them is expensive, see FillCommits.
+	ListReleases(ctx context.Context, token *token.Token, opts *ListOptions, page int) ([]*Release, int, error)
+
+	// FillCommits sets the commit of the provided releases, as returned
+	// by ListReleases, that don't have one set yet. It is called once
+	// per List with only the releases that are being returned.
+	FillCommits(ctx context.Context, token *token.Token, opts *ListOptions, releases []*Release) error
+
+	// UploadAssets uploads the provided files to an existing release,
+	// using the base name of each file as the name of its asset.
+	UploadAssets(ctx context.Context, token *token.Token, opts *UploadOptions, files []*os.File) error
+}
+
+// PublishOrderedFetcher is an optional interface that can be
+// implemented by a [Fetcher] whose ListReleases returns releases in
+// the order used by List: unpublished releases first, then by publish
+// date (newest first). This allows List to stop fetching pages once it
+// has enough releases to satisfy [ListOptions.Limit].
+type PublishOrderedFetcher interface {
+	// ListsByPublishDate returns true if ListReleases returns releases
+	// ordered by publish date.
+	ListsByPublishDate() bool
+}
+
+// Release contains information about a release as returned by a VCS
+// provider. Releases are returned by GetRelease and List, Fetch only
+// returns the requested asset and its file information.
+type Release struct {
+	// Tag is the tag of the release.
+	Tag string
+
+	// Name is the name (title) of the release.
+	Name string
+
+	// Notes are the release notes of the release.
+	Notes string
+
+	// Author is the username of the user that published the release.
+	Author string
+
+	// CreatedAt is when the release was created.
+	CreatedAt time.Time
+
+	// PublishedAt is when the release was published. This may be zero
+	// if the release has not been published yet (e.g., a draft).
+	PublishedAt time.Time
+
+	// Commit is the SHA of the commit that the release's tag points to.
+	// Annotated tags are peeled to the commit they point to.
+	Commit string
 }
 
 // FetchOptions is a set of options for Fetch
 type FetchOptions struct {
 	Overrides []vcs.Override
 
+	// Fetcher, if set, is used instead of the fetcher for the VCS
+	// provider detected from RepoURL. If the provider can be detected,
+	// its token is passed to the fetcher, otherwise an unauthenticated
+	// token is.
+	Fetcher Fetcher
+
 	// RepoURL is the repository URL, it should be a valid
 	// URL.
 	RepoURL string
@@ -57,12 +129,47 @@ type FetchOptions struct {
 	// AssetNames is a list of asset names to fetch, the first
 	// asset that matches will be returned. Globs are supported.
 	AssetNames []string
+
+	// Inspector, if set, is called with the contents of the asset as it
+	// is downloaded (e.g., for virus scanning or validating magic
+	// bytes). If it returns an error, the download is aborted and the
+	// error is returned instead of the asset. Inspector does not need to
+	// read the entire asset.
+	//
+	// Because the asset must be accepted before being returned, it is
+	// downloaded to a temporary file first which is removed when the
+	// returned io.ReadCloser is closed.
+	Inspector func(fs.FileInfo, io.Reader) error
 }
 
 // GetReleaseNoteOptions is a set of options for GetReleaseNotes
 type GetReleaseNoteOptions struct {
 	Overrides []vcs.Override
 
+	// Fetcher, if set, is used instead of the fetcher for the VCS
+	// provider detected from RepoURL. If the provider can be detected,
+	// its token is passed to the fetcher, otherwise an unauthenticated
+	// token is.
+	Fetcher Fetcher
+
+	// RepoURL is the repository URL, it should be a valid
+	// URL.
+	RepoURL string
+
+	// Tag is the tag of the release
+	Tag string
+}
+
+// GetReleaseOptions is a set of options for GetRelease
+type GetReleaseOptions struct {
+	Overrides []vcs.Override
+
+	// Fetcher, if set, is used instead of the fetcher for the VCS
+	// provider detected from RepoURL. If the provider can be detected,
+	// its token is passed to the fetcher, otherwise an unauthenticated
+	// token is.
+	Fetcher Fetcher
+
 	// RepoURL is the repository URL, it should be a valid
 	// URL.
 	RepoURL string
@@ -70,3 +177,77 @@ type GetReleaseNoteOptions struct {
 	// Tag is the tag of the release
 	Tag string
 }
+
+// ListOptions is a set of options for List
+type ListOptions struct {
+	Overrides []vcs.Override
+
+	// Fetcher, if set, is used instead of the fetcher for the VCS
+	// provider detected from RepoURL. If the provider can be detected,
+	// its token is passed to the fetcher, otherwise an unauthenticated
+	// token is.
+	Fetcher Fetcher
+
+	// RepoURL is the repository URL, it should be a valid
+	// URL.
+	RepoURL string
+
+	// Limit is the maximum number of releases to return. If zero, all
+	// releases are returned.
+	//
+	// Limit only reduces the number of requests made to VCS providers
+	// that list releases by publish date (e.g., Gitlab). Otherwise
+	// (e.g., Github), every page of releases is fetched on each call.
+	Limit int
+
+	// Cursor is the cursor returned by a previous call to List, used to
+	// continue listing releases where it left off.
+	Cursor string
+}
+
+// UploadOptions is a set of options for Upload
+type UploadOptions struct {
+	Overrides []vcs.Override
+
+	// Fetcher, if set, is used instead of the fetcher for the VCS
+	// provider detected from RepoURL. If the provider can be detected,
+	// its token is passed to the fetcher, otherwise an unauthenticated
+	// token is.
+	Fetcher Fetcher
+
+	// RepoURL is the repository URL, it should be a valid
+	// URL.
+	RepoURL string
+
+	// Tag is the tag of the release to upload assets to. The release
+	// must already exist.
+	Tag string
+
+	// Assets are the paths to the files to upload. Assets are named
+	// after the base name of their path, which must be unique.
+	Assets []string
+
+	// SkipChecksums disables generating a "checksums.txt" file
+	// containing the checksums of all Assets, which is otherwise
+	// uploaded alongside them.
+	SkipChecksums bool
+
+	// Sign, if set, signs assets with cosign and uploads the signatures
+	// alongside them. Signing is not done by default, as it requires
+	// cosign and a key or OIDC identity. Unless SkipChecksums is set,
+	// only the checksums file is signed (as it covers all other assets),
+	// otherwise every asset is.
+	Sign *SignOptions
+}
+
+// SignOptions is a set of options for signing assets with cosign.
+type SignOptions struct {
+	// Key is the cosign private key (a path or KMS URI) to sign with. Its
+	// password is read from COSIGN_PASSWORD. If empty, keyless signing
+	// is used, which requires an OIDC identity (e.g., in CI) and also
+	// uploads the signing certificate as "<asset>.pem".
+	Key string
+
+	// Cosign is the path to the cosign binary. Defaults to "cosign".
+	Cosign string
+}
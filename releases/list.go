@@ -0,0 +1,222 @@
+// Copyright (C) 2024 vcs contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package releases
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jaredallard/vcs/releases/internal/opts"
+)
+
+// ListOptions is an alias for [opts.ListOptions].
+type ListOptions = opts.ListOptions
+
+// PublishOrderedFetcher is an alias for [opts.PublishOrderedFetcher].
+type PublishOrderedFetcher = opts.PublishOrderedFetcher
+
+// ListResult is returned by [List].
+type ListResult struct {
+	// Releases are the releases that were listed.
+	Releases []*Release
+
+	// Cursor can be passed to [ListOptions.Cursor] to continue listing
+	// releases. This is empty when there are no more releases.
+	Cursor string
+}
+
+// cursor is the position of the last release returned by [List],
+// used to resume listing strictly after it.
+type cursor struct {
+	PublishedAt time.Time `json:"p"`
+	CreatedAt   time.Time `json:"c"`
+	Tag         string    `json:"t"`
+}
+
+// encodeCursor returns an opaque cursor pointing after the provided
+// release.
+func encodeCursor(rel *Release) (string, error) {
+	b, err := json.Marshal(&cursor{PublishedAt: rel.PublishedAt, CreatedAt: rel.CreatedAt, Tag: rel.Tag})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// decodeCursor returns the release encoded in the provided cursor, only
+// containing the fields used for sorting. An empty cursor returns nil.
+func decodeCursor(c string) (*Release, error) {
+	if c == "" {
+		return nil, nil
+	}
+
+	var cur cursor
+	b, err := base64.RawURLEncoding.DecodeString(c)
+	if err == nil {
+		err = json.Unmarshal(b, &cur)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid cursor %q", c)
+	}
+
+	return &Release{PublishedAt: cur.PublishedAt, CreatedAt: cur.CreatedAt, Tag: cur.Tag}, nil
+}
+
+// releaseLess returns true if a should be sorted before b. Releases are
+// sorted by publish date, newest first. Releases that have not been
+// published (e.g., drafts) are sorted first. Ties are broken by
+// creation date and then tag to keep ordering stable.
+func releaseLess(a, b *Release) bool {
+	if a.PublishedAt.IsZero() != b.PublishedAt.IsZero() {
+		return a.PublishedAt.IsZero()
+	}
+	if !a.PublishedAt.Equal(b.PublishedAt) {
+		return a.PublishedAt.After(b.PublishedAt)
+	}
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.After(b.CreatedAt)
+	}
+	return a.Tag > b.Tag
+}
+
+// sortReleases sorts releases using [releaseLess].
+func sortReleases(rels []*Release) {
+	sort.SliceStable(rels, func(i, j int) bool {
+		return releaseLess(rels[i], rels[j])
+	})
+}
+
+// releasesAfter sorts the provided releases and returns the ones that
+// are sorted after the provided release. If after is nil, all releases
+// are returned.
+func releasesAfter(rels []*Release, after *Release) []*Release {
+	sortReleases(rels)
+	if after == nil {
+		return rels
+	}
+
+	start := sort.Search(len(rels), func(i int) bool {
+		return releaseLess(after, rels[i])
+	})
+	return rels[start:]
+}
+
+// hasLimit returns true if rels, the releases listed so far by a
+// [PublishOrderedFetcher], contain more than limit releases after the
+// cursor that can't be preceded by releases on later pages. last is
+// the last release of the most recently fetched page, which is at
+// least as new as every release on later pages. rels is sorted in
+// place.
+func hasLimit(rels []*Release, after, last *Release, limit int) bool {
+	if last.PublishedAt.IsZero() {
+		return false
+	}
+
+	rels = releasesAfter(rels, after)
+	if len(rels) <= limit {
+		return false
+	}
+
+	// Releases on later pages may share the publish date of last and be
+	// sorted before it by creation date or tag, so only releases that
+	// are strictly newer than it are settled.
+	return rels[limit].PublishedAt.After(last.PublishedAt)
+}
+
+// List lists the releases of a repository from a VCS provider, ordered
+// by publish date (newest first) with unpublished releases first. If
+// more releases are available than [ListOptions.Limit], the returned
+// [ListResult.Cursor] can be used to continue listing.
+//
+// The cursor points after the last returned release rather than at an
+// offset, so releases created or deleted between calls don't cause
+// releases to be skipped or returned twice. Releases that would be
+// sorted before the cursor (e.g., newly published ones) are not
+// returned when continuing.
+//
+// Fetching stops once enough releases are found to satisfy
+// [ListOptions.Limit] if the fetcher lists releases by publish date
+// (see [PublishOrderedFetcher]), like Gitlab does. Otherwise, e.g., for
+// Github, every page of releases is fetched on each call to sort them,
+// regardless of [ListOptions.Limit].
+func List(ctx context.Context, opt *ListOptions) (*ListResult, error) {
+	if opt == nil {
+		return nil, fmt.Errorf("opts is nil")
+	}
+
+	if opt.RepoURL == "" {
+		return nil, fmt.Errorf("repo url is required")
+	}
+
+	if opt.Limit < 0 {
+		return nil, fmt.Errorf("limit must not be negative")
+	}
+
+	after, err := decodeCursor(opt.Cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	fetcher, t, err := getFetcher(ctx, opt.Fetcher, opt.RepoURL, opt.Overrides)
+	if err != nil {
+		return nil, err
+	}
+
+	ordered := false
+	if o, ok := fetcher.(PublishOrderedFetcher); ok {
+		ordered = o.ListsByPublishDate()
+	}
+
+	rels := make([]*Release, 0)
+	for page := 1; page != 0; {
+		var pageRels []*Release
+		pageRels, page, err = fetcher.ListReleases(ctx, t, opt, page)
+		if err != nil {
+			return nil, err
+		}
+		rels = append(rels, pageRels...)
+
+		if ordered && opt.Limit > 0 && page != 0 && len(pageRels) > 0 &&
+			hasLimit(rels, after, pageRels[len(pageRels)-1], opt.Limit) {
+			break
+		}
+	}
+	rels = releasesAfter(rels, after)
+
+	result := &ListResult{Releases: rels}
+	if opt.Limit > 0 && len(result.Releases) > opt.Limit {
+		result.Releases = result.Releases[:opt.Limit]
+		result.Cursor, err = encodeCursor(result.Releases[opt.Limit-1])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(result.Releases) > 0 {
+		if err := fetcher.FillCommits(ctx, t, opt, result.Releases); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
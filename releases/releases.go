@@ -47,10 +47,55 @@ type GetReleaseNoteOptions = opts.GetReleaseNoteOptions
 // FetchOptions is an alias for [opts.FetchOptions].
 type FetchOptions = opts.FetchOptions
 
+// GetReleaseOptions is an alias for [opts.GetReleaseOptions].
+type GetReleaseOptions = opts.GetReleaseOptions
+
+// Release is an alias for [opts.Release].
+type Release = opts.Release
+
+// ErrAssetNotFound is an alias for [opts.ErrAssetNotFound].
+var ErrAssetNotFound = opts.ErrAssetNotFound
+
+// Fetcher is an alias for [opts.Fetcher]. Implement it to provide a
+// custom fetcher through [FetchOptions.Fetcher] and friends.
+type Fetcher = opts.Fetcher
+
 // Client contains configuration for fetching releases from various VCS
 // providers.
 type Client struct{}
 
+// getFetcher returns the fetcher to use for the provided repository URL
+// along with the token to pass to it. If override is set, it is always
+// returned.
+func getFetcher(ctx context.Context, override Fetcher, repoURL string, overrides []vcs.Override) (Fetcher, *token.Token, error) {
+	vcsp, err := vcs.ProviderFromURL(repoURL, overrides)
+	if err != nil {
+		if override != nil {
+			// Custom fetchers may support providers we don't know about, so
+			// give them an unauthenticated token.
+			return override, &token.Token{}, nil
+		}
+
+		return nil, nil, fmt.Errorf("failed to get VCS provider from URL: %w", err)
+	}
+
+	t, err := token.Fetch(ctx, vcsp, true)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch token: %w", err)
+	}
+
+	if override != nil {
+		return override, t, nil
+	}
+
+	fetcher, ok := fetchers[vcsp]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown VCS provider %s", vcsp)
+	}
+
+	return fetcher, t, nil
+}
+
 // Fetch fetches a release from a VCS provider and returns an asset
 // from it as an io.ReadCloser. This must be closed to close the
 // underlying HTTP request.
@@ -69,21 +114,33 @@ func Fetch(ctx context.Context, opts *FetchOptions) (io.ReadCloser, fs.FileInfo,
 		return nil, nil, fmt.Errorf("tag is required")
 	}
 
-	vcsp, err := vcs.ProviderFromURL(opts.RepoURL, opts.Overrides)
+	fetcher, t, err := getFetcher(ctx, opts.Fetcher, opts.RepoURL, opts.Overrides)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to get VCS provider from URL: %w", err)
+		return nil, nil, err
 	}
 
-	token, err := token.Fetch(ctx, vcsp, true)
+	rc, fi, err := fetcher.Fetch(ctx, t, opts)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to fetch token: %w", err)
+		return nil, nil, err
+	}
+
+	// Custom fetchers may not return file information, which everything
+	// else relies on.
+	if fi == nil {
+		rc.Close()
+		return nil, nil, fmt.Errorf("fetcher returned no file information for asset")
+	}
+
+	if opts.Inspector == nil {
+		return rc, fi, nil
 	}
 
-	if fetcher, ok := fetchers[vcsp]; ok {
-		return fetcher.Fetch(ctx, token, opts)
+	rc, err = inspect(rc, fi, opts.Inspector)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return nil, nil, fmt.Errorf("unknown VCS provider %s", vcsp)
+	return rc, fi, nil
 }
 
 // GetReleaseNotes fetches the release notes of a release from a VCS provider.
@@ -100,19 +157,33 @@ func GetReleaseNotes(ctx context.Context, opt *GetReleaseNoteOptions) (string, e
 		return "", fmt.Errorf("tag is required")
 	}
 
-	vcsp, err := vcs.ProviderFromURL(opt.RepoURL, opt.Overrides)
+	fetcher, t, err := getFetcher(ctx, opt.Fetcher, opt.RepoURL, opt.Overrides)
 	if err != nil {
-		return "", fmt.Errorf("failed to get VCS provider from URL: %w", err)
+		return "", err
 	}
 
-	t, err := token.Fetch(ctx, vcsp, true)
-	if err != nil {
-		return "", fmt.Errorf("failed to fetch token: %w", err)
+	return fetcher.GetReleaseNotes(ctx, t, opt)
+}
+
+// GetRelease fetches information about a release from a VCS provider,
+// such as who published it, when and the commit its tag points to.
+func GetRelease(ctx context.Context, opt *GetReleaseOptions) (*Release, error) {
+	if opt == nil {
+		return nil, fmt.Errorf("opts is nil")
+	}
+
+	if opt.RepoURL == "" {
+		return nil, fmt.Errorf("repo url is required")
 	}
 
-	if fetcher, ok := fetchers[vcsp]; ok {
-		return fetcher.GetReleaseNotes(ctx, t, opt)
+	if opt.Tag == "" {
+		return nil, fmt.Errorf("tag is required")
+	}
+
+	fetcher, t, err := getFetcher(ctx, opt.Fetcher, opt.RepoURL, opt.Overrides)
+	if err != nil {
+		return nil, err
 	}
 
-	return "", fmt.Errorf("unknown VCS provider %s", vcsp)
+	return fetcher.GetRelease(ctx, t, opt)
 }
@@ -1,13 +1,20 @@
 package releases
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"io"
+	"io/fs"
+	"strings"
 	"testing"
+	"testing/iotest"
+	"time"
 
 	"github.com/jaredallard/vcs"
+	"github.com/jaredallard/vcs/internal/fileinfo"
 )
 
 func TestFetch(t *testing.T) {
@@ -207,3 +214,193 @@ func TestGetReleaseNotes(t *testing.T) {
 		})
 	}
 }
+
+func TestChecksums(t *testing.T) {
+	c := make(Checksums)
+	if err := c.Add("b.tar.gz", strings.NewReader("b")); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := c.Add("a.tar.gz", strings.NewReader("a")); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := c.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo() error = %v", err)
+	}
+
+	want := "ca978112ca1bbdcafac231b39a23dc4da786eff8147c4e72b9807785afee48bb  a.tar.gz\n" +
+		"3e23e8160039594a33894f6564e1b1348bbd7a0088d42c4acb73eeaed59c009d  b.tar.gz\n"
+	if buf.String() != want {
+		t.Errorf("WriteTo() = %q, want %q", buf.String(), want)
+	}
+
+	parsed, err := ParseChecksums(&buf)
+	if err != nil {
+		t.Fatalf("ParseChecksums() error = %v", err)
+	}
+	if err := parsed.Verify("a.tar.gz", strings.NewReader("a")); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+	if err := parsed.Verify("b.tar.gz", strings.NewReader("a")); !errors.Is(err, ErrChecksumMismatch) {
+		t.Errorf("Verify() error = %v, want %v", err, ErrChecksumMismatch)
+	}
+	if err := parsed.Verify("c.tar.gz", strings.NewReader("c")); err == nil {
+		t.Errorf("Verify() expected error for unknown asset")
+	}
+
+	// Names may contain whitespace.
+	parsed, err = ParseChecksums(strings.NewReader(
+		"ca978112ca1bbdcafac231b39a23dc4da786eff8147c4e72b9807785afee48bb *my asset.tar.gz\n",
+	))
+	if err != nil {
+		t.Fatalf("ParseChecksums() error = %v", err)
+	}
+	if err := parsed.Verify("my asset.tar.gz", strings.NewReader("a")); err != nil {
+		t.Errorf("Verify() error = %v", err)
+	}
+}
+
+func TestGetRelease(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    *GetReleaseOptions
+		wantErr bool
+	}{
+		{
+			name: "should get a github release",
+			opts: &GetReleaseOptions{
+				RepoURL: "https://github.com/rgst-io/stencil",
+				Tag:     "v0.7.0",
+			},
+		},
+		{
+			name: "should get a gitlab release",
+			opts: &GetReleaseOptions{
+				RepoURL: "https://gitlab.com/jaredallard/vcs-test-repo",
+				Tag:     "v0.1.0",
+			},
+		},
+		{
+			name:    "should fail when no opts given",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := GetRelease(context.Background(), tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetRelease() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if got.Tag != tt.opts.Tag {
+				t.Errorf("GetRelease() tag = %v, want %v", got.Tag, tt.opts.Tag)
+			}
+			if got.Author == "" || got.Commit == "" || got.PublishedAt.IsZero() {
+				t.Errorf("GetRelease() returned incomplete release: %+v", got)
+			}
+		})
+	}
+}
+
+func TestInspect(t *testing.T) {
+	errRejected := errors.New("rejected")
+	contents := strings.Repeat("a", 64*1024)
+
+	tests := []struct {
+		name      string
+		inspector func(fs.FileInfo, io.Reader) error
+		wantErr   error
+	}{
+		{
+			name: "should return asset when accepted",
+			inspector: func(_ fs.FileInfo, r io.Reader) error {
+				_, err := io.Copy(io.Discard, r)
+				return err
+			},
+		},
+		{
+			name: "should return asset when accepted without reading it all",
+			inspector: func(_ fs.FileInfo, r io.Reader) error {
+				_, err := r.Read(make([]byte, 1))
+				return err
+			},
+		},
+		{
+			name: "should fail when rejected",
+			inspector: func(_ fs.FileInfo, r io.Reader) error {
+				if _, err := r.Read(make([]byte, 1)); err != nil {
+					return err
+				}
+				return errRejected
+			},
+			wantErr: errRejected,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fi := fileinfo.New("asset.tar.gz", int64(len(contents)), time.Now(), nil)
+
+			got, err := inspect(io.NopCloser(strings.NewReader(contents)), fi, tt.inspector)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("inspect() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			defer got.Close()
+
+			b, err := io.ReadAll(got)
+			if err != nil {
+				t.Fatalf("inspect() read error = %v", err)
+			}
+			if string(b) != contents {
+				t.Errorf("inspect() returned %d bytes, want %d", len(b), len(contents))
+			}
+		})
+	}
+}
+
+func TestInspectReportsDownloadFailures(t *testing.T) {
+	errBroken := errors.New("connection reset")
+	rc := io.NopCloser(io.MultiReader(strings.NewReader("abc"), iotest.ErrReader(errBroken)))
+	fi := fileinfo.New("asset.tar.gz", 0, time.Now(), nil)
+
+	// The inspector returns the error it read, which must not be treated
+	// as a rejection.
+	_, err := inspect(rc, fi, func(_ fs.FileInfo, r io.Reader) error {
+		_, err := io.Copy(io.Discard, r)
+		return err
+	})
+	if !errors.Is(err, errBroken) || !strings.Contains(err.Error(), "failed to download asset") {
+		t.Errorf("inspect() error = %v, want download failure", err)
+	}
+}
+
+func TestListProviders(t *testing.T) {
+	for _, repoURL := range []string{
+		"https://github.com/rgst-io/stencil",
+		"https://gitlab.com/jaredallard/vcs-test-repo",
+	} {
+		t.Run(repoURL, func(t *testing.T) {
+			t.Parallel()
+
+			res, err := List(context.Background(), &ListOptions{RepoURL: repoURL, Limit: 1})
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			if len(res.Releases) != 1 {
+				t.Fatalf("List() returned %d releases, want 1", len(res.Releases))
+			}
+			if res.Releases[0].Commit == "" {
+				t.Errorf("List() returned release %s without a commit", res.Releases[0].Tag)
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+// Copyright (C) 2024 vcs contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package releases
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/jaredallard/cmdexec"
+	"github.com/jaredallard/vcs/internal/execerr"
+	"github.com/jaredallard/vcs/releases/internal/opts"
+)
+
+// UploadOptions is an alias for [opts.UploadOptions].
+type UploadOptions = opts.UploadOptions
+
+// SignOptions is an alias for [opts.SignOptions].
+type SignOptions = opts.SignOptions
+
+// Upload uploads assets to an existing release on a VCS provider. A
+// checksums file (see [ChecksumsFileName]) and, if requested, cosign
+// signatures are generated and uploaded alongside them. If the token is
+// known to lack the "contents:write" permission (see
+// [token.Token.RequirePermission]), nothing is uploaded.
+func Upload(ctx context.Context, opt *UploadOptions) error {
+	if opt == nil {
+		return fmt.Errorf("opts is nil")
+	}
+
+	if opt.RepoURL == "" {
+		return fmt.Errorf("repo url is required")
+	}
+
+	if opt.Tag == "" {
+		return fmt.Errorf("tag is required")
+	}
+
+	fetcher, t, err := getFetcher(ctx, opt.Fetcher, opt.RepoURL, opt.Overrides)
+	if err != nil {
+		return err
+	}
+
+	// Fail early instead of with an opaque error from the VCS provider
+	// halfway through uploading.
+	if err := t.RequirePermission("contents", "write"); err != nil {
+		return fmt.Errorf("unable to upload assets to %s: %w", opt.RepoURL, err)
+	}
+
+	// Generated files are written to a temporary directory so that they
+	// can be named after the asset they belong to.
+	tempDir, err := os.MkdirTemp("", "vcs-upload-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	files := append([]string{}, opt.Assets...)
+	signed := opt.Assets
+	if !opt.SkipChecksums {
+		checksumsPath, err := writeChecksums(opt.Assets, tempDir)
+		if err != nil {
+			return err
+		}
+
+		files = append(files, checksumsPath)
+		signed = []string{checksumsPath}
+	}
+
+	if opt.Sign != nil {
+		for _, path := range signed {
+			companions, err := sign(ctx, opt.Sign, path, tempDir)
+			if err != nil {
+				return err
+			}
+			files = append(files, companions...)
+		}
+	}
+
+	names := make(map[string]struct{}, len(files))
+	for _, path := range files {
+		name := filepath.Base(path)
+		if _, ok := names[name]; ok {
+			return fmt.Errorf("multiple assets named %s", name)
+		}
+		names[name] = struct{}{}
+	}
+
+	opened := make([]*os.File, 0, len(files))
+	for _, path := range files {
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to open asset: %w", err)
+		}
+		defer f.Close()
+
+		opened = append(opened, f)
+	}
+
+	return fetcher.UploadAssets(ctx, t, opt, opened)
+}
+
+// writeChecksums writes a checksums file for the provided assets into
+// dir, returning its path.
+func writeChecksums(assets []string, dir string) (string, error) {
+	c := make(Checksums, len(assets))
+	for _, path := range assets {
+		f, err := os.Open(path)
+		if err != nil {
+			return "", fmt.Errorf("failed to open asset: %w", err)
+		}
+
+		err = c.Add(filepath.Base(path), f)
+		f.Close()
+		if err != nil {
+			return "", err
+		}
+	}
+
+	path := filepath.Join(dir, ChecksumsFileName)
+	f, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to create checksums file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := c.WriteTo(f); err != nil {
+		return "", fmt.Errorf("failed to write checksums file: %w", err)
+	}
+
+	return path, f.Close()
+}
+
+// sign signs the file at path with cosign, writing the signature (and
+// certificate, when signing keyless) into dir. The paths of the written
+// files are returned.
+func sign(ctx context.Context, opt *SignOptions, path, dir string) ([]string, error) {
+	cosign := opt.Cosign
+	if cosign == "" {
+		cosign = "cosign"
+	}
+
+	name := filepath.Base(path)
+	sigPath := filepath.Join(dir, name+".sig")
+	files := []string{sigPath}
+
+	args := []string{"sign-blob", "--yes", "--output-signature", sigPath}
+	if opt.Key != "" {
+		args = append(args, "--key", opt.Key)
+	} else {
+		certPath := filepath.Join(dir, name+".pem")
+		args = append(args, "--output-certificate", certPath)
+		files = append(files, certPath)
+	}
+	args = append(args, path)
+
+	if _, err := cmdexec.CommandContext(ctx, cosign, args...).Output(); err != nil {
+		return nil, fmt.Errorf("failed to sign %s: %w", name, execerr.From(err))
+	}
+
+	return files, nil
+}
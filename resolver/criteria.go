@@ -55,6 +55,16 @@ type Criteria struct {
 	// versions. For this reason, top-level modules should only ever use
 	// branches.
 	Branch string
+
+	// TagsOnBranch restricts the versions being considered to tags that
+	// are reachable from the provided branch (i.e., tags that were cut
+	// from the branch or one of its ancestors). This is useful for
+	// tracking long-term support branches, where the globally newest tag
+	// is likely on a different release line.
+	//
+	// If no constraint is provided, the newest (non pre-release) tag on
+	// the branch is selected.
+	TagsOnBranch string
 }
 
 // Parse parses the criteria's constraint into a semver constraint. If
@@ -62,6 +72,13 @@ type Criteria struct {
 func (c *Criteria) Parse() error {
 	var err error
 	c.once.Do(func() {
+		if c.Constraint == "" && c.TagsOnBranch != "" {
+			// Any tag on the branch satisfies us, so use a wildcard
+			// constraint without changing the user-provided one.
+			c.c, err = semver.NewConstraint("*")
+			return
+		}
+
 		if c.Constraint == "" {
 			// No constraint, no need to parse.
 			return
@@ -121,9 +138,8 @@ func (c *Criteria) Check(v *Version, prerelease, branch string) bool {
 		// If we're eligible for pre-releases but our constraint doesn't
 		// allow for them, then we need to change our constraint to allow
 		// for pre-releases.
-		if prerelease != "" && c.prerelease == "" {
-			// We need to add the pre-release to the constraint.
-			c.Constraint = fmt.Sprintf("%s-%s", c.Constraint, prerelease)
+		if widened, ok := c.widen(prerelease); ok {
+			c.Constraint = widened
 
 			// TODO(jaredallard): Better error handling and location for this logic since
 			// doing this on every call is pretty awful and inefficient.
@@ -144,6 +160,23 @@ func (c *Criteria) Check(v *Version, prerelease, branch string) bool {
 	return false
 }
 
+// widen returns the constraint that should be used to allow for the
+// provided pre-release, if the criteria's constraint needs to be
+// widened for it. The criteria must have been parsed already.
+func (c *Criteria) widen(prerelease string) (string, bool) {
+	if c.c == nil || prerelease == "" || c.prerelease != "" {
+		return "", false
+	}
+
+	constraint := c.Constraint
+	if constraint == "" {
+		// Parsed into a wildcard (see TagsOnBranch).
+		constraint = "*"
+	}
+
+	return fmt.Sprintf("%s-%s", constraint, prerelease), true
+}
+
 // Equal returns true if the criteria is equal to the other criteria.
 func (c *Criteria) Equal(other *Criteria) bool {
 	// If either is nil, they must both be nil.
@@ -152,7 +185,7 @@ func (c *Criteria) Equal(other *Criteria) bool {
 	}
 
 	// Otherwise, check all fields.
-	return c.Constraint == other.Constraint && c.Branch == other.Branch
+	return c.Constraint == other.Constraint && c.Branch == other.Branch && c.TagsOnBranch == other.TagsOnBranch
 }
 
 // String returns a user-friendly representation of the criteria.
@@ -161,5 +194,13 @@ func (c *Criteria) String() string {
 		return fmt.Sprintf("branch %s", c.Branch)
 	}
 
+	if c.TagsOnBranch != "" {
+		if c.Constraint == "" {
+			return fmt.Sprintf("tags on branch %s", c.TagsOnBranch)
+		}
+
+		return fmt.Sprintf("%s (tags on branch %s)", c.Constraint, c.TagsOnBranch)
+	}
+
 	return c.Constraint
 }
@@ -0,0 +1,118 @@
+// Copyright (C) 2024 vcs contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package resolver
+
+import (
+	"context"
+	"errors"
+)
+
+// Explanation describes how a set of criteria is interpreted when
+// resolving versions. See [Resolver.Explain].
+type Explanation struct {
+	// Prerelease is the pre-release track (e.g., "rc") that was selected
+	// from the criteria, if any. When set, all constraints are widened to
+	// allow for pre-releases on this track.
+	Prerelease string
+
+	// Branch is the branch that is being resolved, if any. When set,
+	// constraints are not considered.
+	Branch string
+
+	// TagsOnBranch is the branch that tags must be reachable from, if
+	// any.
+	TagsOnBranch string
+
+	// Constraints contains the effective constraint of each of the
+	// provided criteria, in the same order.
+	Constraints []EffectiveConstraint
+
+	// Version is the version that would be selected, or nil if no
+	// version satisfies the criteria.
+	Version *Version
+}
+
+// EffectiveConstraint is the constraint that is used for a criteria
+// during resolution.
+type EffectiveConstraint struct {
+	// Criteria is the criteria as provided by the caller.
+	Criteria *Criteria
+
+	// Constraint is the semantic versioning constraint that versions are
+	// checked against. This is empty if the criteria has no constraint,
+	// or if a branch is being resolved.
+	Constraint string
+
+	// Widened is true if Constraint differs from the criteria's
+	// constraint because it was widened to allow for pre-releases.
+	Widened bool
+}
+
+// Explain reports how the provided criteria would be used to resolve a
+// version from the repository at the provided URI, without modifying
+// them. Notably, this includes the effective constraint of each
+// criteria: if any criteria opts into a pre-release track (e.g.,
+// ">=1.0.0-rc"), then all other constraints are implicitly widened to
+// allow for pre-releases on the same track (e.g., ">=1.0.0" becomes
+// ">=1.0.0-rc").
+//
+// Errors returned by [Resolver.Resolve] for invalid or conflicting
+// criteria are returned as-is. If no version satisfies the criteria, an
+// explanation is still returned with a nil Version.
+func (r *Resolver) Explain(ctx context.Context, uri string, criteria ...*Criteria) (*Explanation, error) {
+	// Resolving mutates criteria, so work on copies of them.
+	clones := make([]*Criteria, len(criteria))
+	for i, c := range criteria {
+		clones[i] = &Criteria{Constraint: c.Constraint, Branch: c.Branch, TagsOnBranch: c.TagsOnBranch}
+	}
+
+	prerelease, branch, tagsOnBranch, err := parseCriteria(clones)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &Explanation{
+		Prerelease:   prerelease,
+		Branch:       branch,
+		TagsOnBranch: tagsOnBranch,
+		Constraints:  make([]EffectiveConstraint, len(criteria)),
+	}
+	for i, c := range clones {
+		ec := EffectiveConstraint{Criteria: criteria[i]}
+		if branch == "" && c.c != nil {
+			ec.Constraint = c.Constraint
+			if ec.Constraint == "" {
+				ec.Constraint = "*"
+			}
+
+			if widened, ok := c.widen(prerelease); ok {
+				ec.Constraint = widened
+				ec.Widened = true
+			}
+		}
+		e.Constraints[i] = ec
+	}
+
+	e.Version, err = r.resolve(ctx, uri, clones, prerelease, branch, tagsOnBranch)
+	if err != nil && !errors.Is(err, ErrUnableToSatisfy) {
+		return nil, err
+	}
+
+	return e, nil
+}
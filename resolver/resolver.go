@@ -42,21 +42,88 @@ var ErrUnableToSatisfy = errors.New("no versions found that satisfy criteria")
 // based on the provided criteria. Version lists are fetched exactly
 // once and are cached for the lifetime of the resolver.
 type Resolver struct {
+	// Mirrors is a map of URIs to alternate remotes (mirrors) for them.
+	// When resolving a URI, the versions available on the URI and all
+	// of its mirrors are combined. If a version is available on multiple
+	// remotes, the first reachable one (the URI itself, then mirrors in
+	// order) provides it. Versions provided by a mirror have
+	// [Version.Remote] set to it.
+	//
+	// Mirrors must be set before resolving the URI for the first time.
+	Mirrors map[string][]string
+
+	// ListRemoteOptions are the options used when listing versions on a
+	// remote (see [git.ListRemote]). This can be used to, for example,
+	// enable resolving from on-disk snapshots when offline.
+	//
+	// Snapshots only contain the refs of a remote, not their ancestry, so
+	// criteria using [Criteria.TagsOnBranch] always require the network
+	// and fail to resolve when Offline is set.
+	ListRemoteOptions *git.ListRemoteOptions
+
 	// versions is a map of URIs to versions that have been fetched.
 	versions map[string][]Version
 
 	// versionsMu is a mutex that protects the versions map, allowing
 	// for concurrent access.
 	versionsMu sync.Mutex
+
+	// branchTags is a map of URI and branch (see [branchTagsKey]) to the
+	// set of tags reachable from that branch.
+	branchTags map[string]map[string]struct{}
+
+	// branchTagsMu is a mutex that protects the branchTags map.
+	branchTagsMu sync.Mutex
 }
 
 // NewResolver creates a new resolver instance.
 func NewResolver() *Resolver {
 	return &Resolver{
-		versions: make(map[string][]Version),
+		versions:   make(map[string][]Version),
+		branchTags: make(map[string]map[string]struct{}),
 	}
 }
 
+// branchTagsKey returns the key used in the branchTags map for the
+// provided URI and branch.
+func branchTagsKey(uri, branch string) string {
+	return uri + "@" + branch
+}
+
+// fetchBranchTagsIfNecessary returns the set of tags that are reachable
+// from the provided branch. Like versions, these are fetched exactly
+// once per URI and branch.
+func (r *Resolver) fetchBranchTagsIfNecessary(ctx context.Context, uri, branch string) (map[string]struct{}, error) {
+	r.branchTagsMu.Lock()
+	defer r.branchTagsMu.Unlock()
+
+	if r.branchTags == nil {
+		r.branchTags = make(map[string]map[string]struct{})
+	}
+
+	key := branchTagsKey(uri, branch)
+	if tags, ok := r.branchTags[key]; ok {
+		return tags, nil
+	}
+
+	if r.ListRemoteOptions != nil && r.ListRemoteOptions.Offline {
+		return nil, fmt.Errorf("unable to determine tags on branch %s while offline", branch)
+	}
+
+	tagList, err := git.ListTagsOnBranch(ctx, uri, branch)
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine tags on branch %s: %w", branch, err)
+	}
+
+	tags := make(map[string]struct{}, len(tagList))
+	for _, tag := range tagList {
+		tags[tag] = struct{}{}
+	}
+	r.branchTags[key] = tags
+
+	return tags, nil
+}
+
 // fetchVersionsIfNecessary fetches versions for the provided URI if not
 // already fetched. If versions are already fetched, they are returned
 // immediately.
@@ -77,12 +144,130 @@ func (r *Resolver) fetchVersionsIfNecessary(ctx context.Context, uri string) ([]
 		return versions, nil
 	}
 
-	// Fetch versions for the URI.
-	remoteStrs, err := git.ListRemote(ctx, uri)
+	// Fetch versions from the URI and all of its mirrors.
+	versions, err := unionVersions(r.remotes(uri), func(remote string) ([][]string, error) {
+		return git.ListRemote(ctx, remote, r.ListRemoteOptions)
+	})
 	if err != nil {
 		return nil, err
 	}
 
+	// Write the versions to the cache.
+	r.versions[uri] = versions
+
+	return versions, nil
+}
+
+// Prefetch fetches the versions of all of the provided URIs (and their
+// mirrors) that haven't been fetched yet, listing at most concurrency
+// remotes at a time (see [git.ListRemotes]). This makes resolving a
+// large number of URIs significantly faster than resolving them one at
+// a time, especially when combined with
+// [git.ListRemoteOptions.ReuseConnections].
+//
+// URIs that could not be fetched are not cached, and an error
+// containing all of the failures is returned. Fetching is retried when
+// resolving them.
+func (r *Resolver) Prefetch(ctx context.Context, concurrency int, uris ...string) error {
+	r.versionsMu.Lock()
+	defer r.versionsMu.Unlock()
+
+	if r.versions == nil {
+		r.versions = make(map[string][]Version)
+	}
+
+	// Determine all remotes that need to be listed, only listing each
+	// one once.
+	pending := make([]string, 0, len(uris))
+	remotes := make([]string, 0, len(uris))
+	seen := make(map[string]struct{})
+	for _, uri := range uris {
+		if _, ok := r.versions[uri]; ok {
+			continue
+		}
+		pending = append(pending, uri)
+
+		for _, remote := range r.remotes(uri) {
+			if _, ok := seen[remote]; ok {
+				continue
+			}
+			seen[remote] = struct{}{}
+			remotes = append(remotes, remote)
+		}
+	}
+
+	results, err := git.ListRemotes(ctx, remotes, concurrency, r.ListRemoteOptions)
+	if err != nil {
+		return err
+	}
+
+	byRemote := make(map[string]*git.ListRemoteResult, len(results))
+	for i := range results {
+		byRemote[results[i].Remote] = &results[i]
+	}
+
+	errs := make([]error, 0)
+	for _, uri := range pending {
+		versions, err := unionVersions(r.remotes(uri), func(remote string) ([][]string, error) {
+			return byRemote[remote].Remotes, byRemote[remote].Err
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to fetch versions for %s: %w", uri, err))
+			continue
+		}
+
+		r.versions[uri] = versions
+	}
+
+	return errors.Join(errs...)
+}
+
+// remotes returns the remotes that versions of the provided URI are
+// fetched from: the URI itself, then its mirrors.
+func (r *Resolver) remotes(uri string) []string {
+	return append([]string{uri}, r.Mirrors[uri]...)
+}
+
+// unionVersions returns the versions available on all of the provided
+// remotes, as listed by list. Versions are unioned, with the first
+// remote that has a version winning. The first remote is the URI being
+// resolved, versions provided by any other remote (mirror) have their
+// Remote set. An error is only returned if none of the remotes could be
+// listed.
+func unionVersions(remotes []string, list func(remote string) ([][]string, error)) ([]Version, error) {
+	versions := make([]Version, 0)
+	seen := make(map[string]struct{})
+	errs := make([]error, 0)
+	for _, remote := range remotes {
+		remoteStrs, err := list(remote)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		for _, v := range parseVersions(remoteStrs) {
+			if _, ok := seen[v.GitRef()]; ok {
+				continue
+			}
+			seen[v.GitRef()] = struct{}{}
+
+			if remote != remotes[0] {
+				v.Remote = remote
+			}
+			versions = append(versions, v)
+		}
+	}
+	if len(errs) == len(remotes) {
+		// None of the remotes were reachable.
+		return nil, errors.Join(errs...)
+	}
+
+	return versions, nil
+}
+
+// parseVersions converts the output of [git.ListRemote] into versions.
+// Tags that do not follow semantic versioning are ignored.
+func parseVersions(remoteStrs [][]string) []Version {
 	versions := make([]Version, 0)
 	for _, remoteStr := range remoteStrs {
 		if len(remoteStr) != 2 {
@@ -122,10 +307,7 @@ func (r *Resolver) fetchVersionsIfNecessary(ctx context.Context, uri string) ([]
 		}
 	}
 
-	// Write the versions to the cache.
-	r.versions[uri] = versions
-
-	return versions, nil
+	return versions
 }
 
 // Resolve returns the latest version matching the provided criteria.
@@ -135,32 +317,50 @@ func (r *Resolver) fetchVersionsIfNecessary(ctx context.Context, uri string) ([]
 // TODO(jaredallard): Return resolution errors as a type that can be
 // unwrapped for getting information about why it failed.
 func (r *Resolver) Resolve(ctx context.Context, uri string, criteria ...*Criteria) (*Version, error) {
+	prerelease, branch, tagsOnBranch, err := parseCriteria(criteria)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.resolve(ctx, uri, criteria, prerelease, branch, tagsOnBranch)
+}
+
+// parseCriteria parses the provided criteria so that Check() can be
+// called on them later, returning the "wins once" criteria (pre-release
+// track, branch and tags on branch) that apply to all of them.
+func parseCriteria(criteria []*Criteria) (prerelease, branch, tagsOnBranch string, err error) {
 	if len(criteria) == 0 {
-		return nil, fmt.Errorf("no criteria provided")
+		return "", "", "", fmt.Errorf("no criteria provided")
 	}
 
-	// Parse the criteria so we can call Check() later, but also to see if
-	// we have any "wins once" criteria (prerelease track and branches).
-	var prerelease string
-	var branch string
 	for _, criterion := range criteria {
 		if criterion.Branch != "" {
 			if branch != "" && branch != criterion.Branch {
-				return nil, fmt.Errorf("unable to satisfy multiple branch constraints (%s, %s)", branch, criterion.Branch)
+				return "", "", "", fmt.Errorf("unable to satisfy multiple branch constraints (%s, %s)", branch, criterion.Branch)
 			}
 
 			branch = criterion.Branch
 		}
 
+		if criterion.TagsOnBranch != "" {
+			if tagsOnBranch != "" && tagsOnBranch != criterion.TagsOnBranch {
+				return "", "", "", fmt.Errorf(
+					"unable to satisfy multiple tags on branch constraints (%s, %s)", tagsOnBranch, criterion.TagsOnBranch,
+				)
+			}
+
+			tagsOnBranch = criterion.TagsOnBranch
+		}
+
 		if err := criterion.Parse(); err != nil {
-			return nil, fmt.Errorf("failed to parse criteria: %w", err)
+			return "", "", "", fmt.Errorf("failed to parse criteria: %w", err)
 		}
 
 		// See if pre-releases are included in any of the provided
 		// constraints.
 		if criterion.c != nil && criterion.prerelease != "" {
 			if prerelease != "" && prerelease != criterion.prerelease {
-				return nil, fmt.Errorf(
+				return "", "", "", fmt.Errorf(
 					"unable to satisfy multiple pre-release constraints (%s, %s)", prerelease, criterion.prerelease,
 				)
 			}
@@ -169,6 +369,14 @@ func (r *Resolver) Resolve(ctx context.Context, uri string, criteria ...*Criteri
 		}
 	}
 
+	return prerelease, branch, tagsOnBranch, nil
+}
+
+// resolve returns the latest version matching the provided, already
+// parsed, criteria. See [Resolver.Resolve].
+func (r *Resolver) resolve(
+	ctx context.Context, uri string, criteria []*Criteria, prerelease, branch, tagsOnBranch string,
+) (*Version, error) {
 	versions, err := r.fetchVersionsIfNecessary(ctx, uri)
 	if err != nil {
 		return nil, err
@@ -196,6 +404,25 @@ func (r *Resolver) Resolve(ctx context.Context, uri string, criteria ...*Criteri
 		return versions[i].Branch < versions[j].Branch
 	})
 
+	// If we're only considering tags on a specific branch, filter out
+	// all tags that are not reachable from it.
+	var branchTags map[string]struct{}
+	if tagsOnBranch != "" {
+		// Inspect ancestry on the remote that provides the branch.
+		remote := uri
+		for i := range versions {
+			if versions[i].Branch == tagsOnBranch && versions[i].Remote != "" {
+				remote = versions[i].Remote
+				break
+			}
+		}
+
+		branchTags, err = r.fetchBranchTagsIfNecessary(ctx, remote, tagsOnBranch)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// If we have pre-releases, then we need to make sure that none of the
 	// criteria's are failing due to pre-releases _not_ being included.
 
@@ -204,6 +431,12 @@ func (r *Resolver) Resolve(ctx context.Context, uri string, criteria ...*Criteri
 	for i := range versions {
 		version := &versions[i]
 
+		if branchTags != nil && version.Tag != "" {
+			if _, ok := branchTags[version.Tag]; !ok {
+				continue
+			}
+		}
+
 		var satisfied bool
 		for _, criterion := range criteria {
 			satisfied = criterion.Check(version, prerelease, branch)
@@ -4,8 +4,14 @@ package resolver_test
 
 import (
 	"context"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/jaredallard/vcs/git"
+	"github.com/jaredallard/vcs/internal/testutil"
 	"github.com/jaredallard/vcs/resolver"
 	"gotest.tools/v3/assert"
 )
@@ -178,3 +184,304 @@ func TestCannotMixBranches(t *testing.T) {
 	)
 	assert.ErrorContains(t, err, "unable to satisfy multiple branch constraints (main, master)")
 }
+
+// TestCanResolveTagsOnBranch ensures that the resolver only considers
+// tags reachable from the provided branch when asked to.
+func TestCanResolveTagsOnBranch(t *testing.T) {
+	ctx := context.Background()
+
+	repo := testutil.NewLocalRepo(t,
+		[]string{"commit", "--allow-empty", "-m", "initial"},
+		[]string{"tag", "v1.0.0"},
+		[]string{"checkout", "-b", "release-1"},
+		[]string{"commit", "--allow-empty", "-m", "fix"},
+		[]string{"tag", "v1.0.1"},
+		[]string{"checkout", "main"},
+		[]string{"commit", "--allow-empty", "-m", "feature"},
+		[]string{"tag", "v2.0.0"},
+	)
+
+	r := new(resolver.Resolver)
+
+	v, err := r.Resolve(ctx, repo, &resolver.Criteria{TagsOnBranch: "release-1"})
+	assert.NilError(t, err)
+	assert.Equal(t, v.Tag, "v1.0.1")
+
+	v, err = r.Resolve(ctx, repo, &resolver.Criteria{Constraint: "<1.0.1", TagsOnBranch: "release-1"})
+	assert.NilError(t, err)
+	assert.Equal(t, v.Tag, "v1.0.0")
+
+	v, err = r.Resolve(ctx, repo, &resolver.Criteria{TagsOnBranch: "main"})
+	assert.NilError(t, err)
+	assert.Equal(t, v.Tag, "v2.0.0")
+}
+
+// TestCanResolveTagsOnBranchWithPrereleases ensures that tags on a
+// branch without a constraint can be widened to allow for pre-releases
+// requested by other criteria.
+func TestCanResolveTagsOnBranchWithPrereleases(t *testing.T) {
+	ctx := context.Background()
+
+	repo := testutil.NewLocalRepo(t,
+		[]string{"commit", "--allow-empty", "-m", "initial"},
+		[]string{"tag", "v1.0.0"},
+		[]string{"commit", "--allow-empty", "-m", "next"},
+		[]string{"tag", "v1.1.0-rc.1"},
+	)
+
+	r := new(resolver.Resolver)
+	v, err := r.Resolve(ctx, repo,
+		&resolver.Criteria{TagsOnBranch: "main"},
+		&resolver.Criteria{Constraint: ">=1.1.0-rc"},
+	)
+	assert.NilError(t, err)
+	assert.Equal(t, v.Tag, "v1.1.0-rc.1")
+}
+
+// TestCannotResolveTagsOnBranchOffline ensures that tags on a branch
+// are not resolved when the resolver is asked to be offline, as
+// snapshots do not contain the ancestry needed to do so.
+func TestCannotResolveTagsOnBranchOffline(t *testing.T) {
+	ctx := context.Background()
+
+	repo := testutil.NewLocalRepo(t,
+		[]string{"commit", "--allow-empty", "-m", "initial"},
+		[]string{"tag", "v1.0.0"},
+	)
+	cacheDir := t.TempDir()
+
+	r := &resolver.Resolver{ListRemoteOptions: &git.ListRemoteOptions{Snapshot: true, CacheDir: cacheDir}}
+	_, err := r.Resolve(ctx, repo, &resolver.Criteria{Constraint: ">=1.0.0"})
+	assert.NilError(t, err)
+
+	r = &resolver.Resolver{ListRemoteOptions: &git.ListRemoteOptions{Offline: true, CacheDir: cacheDir}}
+	_, err = r.Resolve(ctx, repo, &resolver.Criteria{TagsOnBranch: "main"})
+	assert.ErrorContains(t, err, "unable to determine tags on branch main while offline")
+}
+
+// TestCannotMixTagsOnBranches ensures that the resolver does not
+// support considering tags from multiple branches.
+func TestCannotMixTagsOnBranches(t *testing.T) {
+	ctx := context.Background()
+
+	r := new(resolver.Resolver)
+
+	_, err := r.Resolve(ctx, "https://github.com/rgst-io/stencil",
+		&resolver.Criteria{
+			TagsOnBranch: "main",
+		},
+		&resolver.Criteria{
+			TagsOnBranch: "release-1",
+		},
+	)
+	assert.ErrorContains(t, err, "unable to satisfy multiple tags on branch constraints (main, release-1)")
+}
+
+// TestCanResolveAcrossMirrors ensures that versions from mirrors are
+// considered and that the first remote with a version wins.
+func TestCanResolveAcrossMirrors(t *testing.T) {
+	ctx := context.Background()
+
+	origin := testutil.NewLocalRepo(t,
+		[]string{"commit", "--allow-empty", "-m", "initial"},
+		[]string{"tag", "v1.0.0"},
+	)
+	mirror := testutil.NewLocalRepo(t,
+		[]string{"commit", "--allow-empty", "-m", "initial"},
+		[]string{"tag", "v1.0.0"},
+		[]string{"commit", "--allow-empty", "-m", "internal"},
+		[]string{"tag", "v1.1.0"},
+	)
+
+	r := &resolver.Resolver{Mirrors: map[string][]string{origin: {mirror}}}
+
+	v, err := r.Resolve(ctx, origin, &resolver.Criteria{Constraint: "*"})
+	assert.NilError(t, err)
+	assert.Equal(t, v.Tag, "v1.1.0")
+	assert.Equal(t, v.Remote, mirror)
+
+	v, err = r.Resolve(ctx, origin, &resolver.Criteria{Constraint: "<1.1.0"})
+	assert.NilError(t, err)
+	assert.Equal(t, v.Tag, "v1.0.0")
+	assert.Equal(t, v.Remote, "")
+}
+
+// TestCanResolveWithUnreachableRemote ensures that an unreachable
+// remote does not prevent resolution if a mirror is reachable.
+func TestCanResolveWithUnreachableRemote(t *testing.T) {
+	ctx := context.Background()
+
+	origin := t.TempDir() // not a git repository
+	mirror := testutil.NewLocalRepo(t,
+		[]string{"commit", "--allow-empty", "-m", "initial"},
+		[]string{"tag", "v1.0.0"},
+	)
+
+	r := &resolver.Resolver{Mirrors: map[string][]string{origin: {mirror}}}
+
+	v, err := r.Resolve(ctx, origin, &resolver.Criteria{Constraint: "*"})
+	assert.NilError(t, err)
+	assert.Equal(t, v.Tag, "v1.0.0")
+	assert.Equal(t, v.Remote, mirror)
+}
+
+// TestPrefetchCachesVersions ensures that versions fetched by Prefetch
+// are used when resolving.
+func TestPrefetchCachesVersions(t *testing.T) {
+	ctx := context.Background()
+
+	a := testutil.NewLocalRepo(t,
+		[]string{"commit", "--allow-empty", "-m", "initial"},
+		[]string{"tag", "v1.0.0"},
+	)
+	b := testutil.NewLocalRepo(t,
+		[]string{"commit", "--allow-empty", "-m", "initial"},
+		[]string{"tag", "v2.0.0"},
+	)
+	missing := t.TempDir() // not a git repository
+
+	r := &resolver.Resolver{}
+	err := r.Prefetch(ctx, 2, a, b, missing)
+	assert.ErrorContains(t, err, "failed to fetch versions for "+missing)
+
+	// Make the remotes unavailable, resolving should use the cache.
+	assert.NilError(t, os.RemoveAll(filepath.Join(a, ".git")))
+	assert.NilError(t, os.RemoveAll(filepath.Join(b, ".git")))
+
+	v, err := r.Resolve(ctx, a, &resolver.Criteria{Constraint: "*"})
+	assert.NilError(t, err)
+	assert.Equal(t, v.Tag, "v1.0.0")
+	assert.Equal(t, v.Remote, "") // Only set for mirrors.
+
+	v, err = r.Resolve(ctx, b, &resolver.Criteria{Constraint: "*"})
+	assert.NilError(t, err)
+	assert.Equal(t, v.Tag, "v2.0.0")
+}
+
+// TestExplainReportsWidenedConstraints ensures that Explain reports the
+// constraints that are used after widening them for pre-releases.
+func TestExplainReportsWidenedConstraints(t *testing.T) {
+	ctx := context.Background()
+
+	repo := testutil.NewLocalRepo(t,
+		[]string{"commit", "--allow-empty", "-m", "initial"},
+		[]string{"tag", "v1.0.0"},
+		[]string{"commit", "--allow-empty", "-m", "next"},
+		[]string{"tag", "v1.1.0-rc.1"},
+	)
+
+	criteria := []*resolver.Criteria{
+		{Constraint: ">=1.0.0"},
+		{Constraint: ">=1.1.0-rc.1"},
+		{TagsOnBranch: "main"},
+	}
+
+	r := &resolver.Resolver{}
+	e, err := r.Explain(ctx, repo, criteria...)
+	assert.NilError(t, err)
+	assert.Equal(t, e.Prerelease, "rc")
+	assert.Equal(t, e.TagsOnBranch, "main")
+	assert.Equal(t, e.Version.Tag, "v1.1.0-rc.1")
+
+	assert.Equal(t, len(e.Constraints), 3)
+	assert.Equal(t, e.Constraints[0].Constraint, ">=1.0.0-rc")
+	assert.Equal(t, e.Constraints[0].Widened, true)
+	assert.Equal(t, e.Constraints[1].Constraint, ">=1.1.0-rc.1")
+	assert.Equal(t, e.Constraints[1].Widened, false)
+	assert.Equal(t, e.Constraints[2].Constraint, "*-rc")
+	assert.Equal(t, e.Constraints[2].Widened, true)
+
+	// The provided criteria must not have been modified.
+	assert.Equal(t, criteria[0].Constraint, ">=1.0.0")
+	assert.Equal(t, criteria[2].Constraint, "")
+
+	// Explaining must match what is resolved.
+	v, err := r.Resolve(ctx, repo, criteria...)
+	assert.NilError(t, err)
+	assert.Equal(t, v.Tag, e.Version.Tag)
+}
+
+// parseTestPins parses a pin file containing lines of "name uri
+// constraint".
+func parseTestPins(b []byte) (map[string]*resolver.Pin, error) {
+	pins := make(map[string]*resolver.Pin)
+	for _, line := range strings.Split(strings.TrimSpace(string(b)), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+		pins[fields[0]] = &resolver.Pin{URI: fields[1], Criteria: []*resolver.Criteria{{Constraint: fields[2]}}}
+	}
+	return pins, nil
+}
+
+// nextEvent returns the next event from events, failing the test if
+// none is received in time.
+func nextEvent(t *testing.T, events <-chan resolver.WatchEvent) resolver.WatchEvent {
+	t.Helper()
+
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(10 * time.Second):
+		t.Fatal("timed out waiting for watch event")
+		return resolver.WatchEvent{}
+	}
+}
+
+// TestWatchReresolvesChangedPins ensures that only pins that changed
+// are re-resolved when a pin file changes.
+func TestWatchReresolvesChangedPins(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	repo := testutil.NewLocalRepo(t,
+		[]string{"commit", "--allow-empty", "-m", "initial"},
+		[]string{"tag", "v1.0.0"},
+		[]string{"commit", "--allow-empty", "-m", "feature"},
+		[]string{"tag", "v1.1.0"},
+	)
+
+	pinFile := filepath.Join(t.TempDir(), "pins.txt")
+	assert.NilError(t, os.WriteFile(pinFile, []byte("a "+repo+" <1.1.0\nb "+repo+" *\nc "+repo+" *\n"), 0o600))
+
+	r := resolver.NewResolver()
+	events, err := r.Watch(ctx, pinFile, parseTestPins)
+	assert.NilError(t, err)
+
+	event := nextEvent(t, events)
+	assert.NilError(t, event.Err)
+	assert.Equal(t, event.Name, "a")
+	assert.Equal(t, event.Version.Tag, "v1.0.0")
+
+	event = nextEvent(t, events)
+	assert.NilError(t, event.Err)
+	assert.Equal(t, event.Name, "b")
+	assert.Equal(t, event.Version.Tag, "v1.1.0")
+
+	event = nextEvent(t, events)
+	assert.NilError(t, event.Err)
+	assert.Equal(t, event.Name, "c")
+
+	// Change "a" and remove "b" and "c".
+	assert.NilError(t, os.WriteFile(pinFile, []byte("a "+repo+" >=1.1.0\n"), 0o600))
+
+	event = nextEvent(t, events)
+	assert.NilError(t, event.Err)
+	assert.Equal(t, event.Name, "a")
+	assert.Equal(t, event.Previous.Tag, "v1.0.0")
+	assert.Equal(t, event.Version.Tag, "v1.1.0")
+
+	event = nextEvent(t, events)
+	assert.Equal(t, event.Name, "b")
+	assert.Assert(t, event.Removed, "expected b to be removed")
+
+	// Removals are emitted in a stable order too.
+	event = nextEvent(t, events)
+	assert.Equal(t, event.Name, "c")
+	assert.Assert(t, event.Removed, "expected c to be removed")
+
+	cancel()
+	_, ok := <-events
+	assert.Assert(t, !ok, "expected events to be closed after cancel")
+}
@@ -45,6 +45,11 @@ type Version struct {
 
 	// Branch is the underlying branch for this version, if set.
 	Branch string `yaml:"branch,omitempty"`
+
+	// Remote is the mirror that this version was discovered on and
+	// should be fetched from (see [Resolver.Mirrors]). This is empty if
+	// the version was discovered on the URI that was resolved.
+	Remote string `yaml:"remote,omitempty"`
 }
 
 // Equal returns true if the two versions are equal.
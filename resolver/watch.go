@@ -0,0 +1,251 @@
+// Copyright (C) 2024 vcs contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package resolver
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce is how long to wait after the last change to a pin file
+// before reloading it. Editors commonly emit multiple events per save.
+var watchDebounce = 100 * time.Millisecond
+
+// Pin is an entry in a pin (or lock) file that should be resolved.
+type Pin struct {
+	// URI is the URI of the repository to resolve versions from.
+	URI string
+
+	// Criteria are the criteria the resolved version must satisfy.
+	Criteria []*Criteria
+}
+
+// key returns a string that uniquely identifies the pin's URI and
+// criteria. This is calculated from the user-provided fields only, so
+// it is stable even after the criteria have been used for resolving.
+func (p *Pin) key() string {
+	var sb strings.Builder
+	sb.WriteString(p.URI)
+	for _, c := range p.Criteria {
+		fmt.Fprintf(&sb, "\x00%s\x00%s\x00%s", c.Constraint, c.Branch, c.TagsOnBranch)
+	}
+	return sb.String()
+}
+
+// PinParser parses the contents of a pin file into pins, keyed by a
+// name that is stable across changes (e.g., a dependency name).
+type PinParser func([]byte) (map[string]*Pin, error)
+
+// WatchEvent is emitted by [Resolver.Watch] when a pin is (re-)resolved
+// or removed, or when the pin file could not be processed.
+type WatchEvent struct {
+	// Name is the name of the pin, as returned by the [PinParser]. This
+	// is empty if Err is set because the pin file failed to be read or
+	// parsed.
+	Name string
+
+	// Pin is the pin that was resolved. This is nil if the pin was
+	// removed.
+	Pin *Pin
+
+	// Version is the newly resolved version. This is nil if the pin was
+	// removed or failed to resolve.
+	Version *Version
+
+	// Previous is the previously resolved version, if any.
+	Previous *Version
+
+	// Removed is true if the pin was removed from the pin file.
+	Removed bool
+
+	// Err is set if the pin file could not be read or parsed, or if the
+	// pin could not be resolved.
+	Err error
+}
+
+// watchedPin is a pin that has been resolved by [Resolver.Watch].
+type watchedPin struct {
+	key     string
+	version *Version
+}
+
+// Watch watches the pin file at path and resolves its pins using this
+// resolver, emitting an event for each pin on the returned channel.
+// Whenever the file changes, only pins that were added or whose URI or
+// criteria changed are re-resolved. Events for removed pins are emitted
+// as well.
+//
+// The file is resolved once immediately. The returned channel is closed
+// once ctx is done. Events must be consumed, otherwise watching blocks.
+//
+// Note: Version lists are cached for the lifetime of the resolver (see
+// [Resolver]), so new tags on a remote are not picked up by watching.
+func (r *Resolver) Watch(ctx context.Context, path string, parse PinParser) (<-chan WatchEvent, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create watcher: %w", err)
+	}
+
+	// Watch the directory instead of the file itself, as editors commonly
+	// replace files (rename) rather than writing to them.
+	if err := w.Add(filepath.Dir(path)); err != nil {
+		w.Close()
+		return nil, fmt.Errorf("failed to watch %s: %w", path, err)
+	}
+
+	events := make(chan WatchEvent)
+	go func() {
+		defer close(events)
+		defer w.Close()
+
+		pins := make(map[string]*watchedPin)
+		if !r.reloadPins(ctx, path, parse, pins, events) {
+			return
+		}
+
+		// Only create the timer once we have a change to debounce.
+		var debounce *time.Timer
+		var debounceC <-chan time.Time
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-w.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) != path || !event.Has(fsnotify.Write|fsnotify.Create|fsnotify.Rename) {
+					continue
+				}
+
+				if debounce == nil {
+					debounce = time.NewTimer(watchDebounce)
+				} else {
+					debounce.Reset(watchDebounce)
+				}
+				debounceC = debounce.C
+			case err, ok := <-w.Errors:
+				if !ok {
+					return
+				}
+				if !sendEvent(ctx, events, WatchEvent{Err: fmt.Errorf("failed to watch %s: %w", path, err)}) {
+					return
+				}
+			case <-debounceC:
+				debounceC = nil
+				if !r.reloadPins(ctx, path, parse, pins, events) {
+					return
+				}
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// reloadPins reads and parses the pin file at path, resolving all pins
+// that changed compared to pins (which is updated in place) and
+// emitting events for them. Returns false if ctx is done.
+func (r *Resolver) reloadPins(
+	ctx context.Context, path string, parse PinParser, pins map[string]*watchedPin, events chan<- WatchEvent,
+) bool {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return sendEvent(ctx, events, WatchEvent{Err: fmt.Errorf("failed to read pin file: %w", err)})
+	}
+
+	newPins, err := parse(b)
+	if err != nil {
+		return sendEvent(ctx, events, WatchEvent{Err: fmt.Errorf("failed to parse pin file: %w", err)})
+	}
+
+	// Process pins in a stable order.
+	names := make([]string, 0, len(newPins))
+	for name := range newPins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		pin := newPins[name]
+		key := pin.key()
+
+		previous, ok := pins[name]
+		if ok && previous.key == key {
+			// Nothing changed, so there's no need to re-resolve.
+			continue
+		}
+
+		event := WatchEvent{Name: name, Pin: pin}
+		if ok {
+			event.Previous = previous.version
+		}
+
+		event.Version, event.Err = r.Resolve(ctx, pin.URI, pin.Criteria...)
+		if event.Err != nil {
+			// Ensure we try again on the next change.
+			key = ""
+		}
+		pins[name] = &watchedPin{key: key, version: event.Version}
+		if !sendEvent(ctx, events, event) {
+			return false
+		}
+	}
+
+	removed := make([]string, 0)
+	for name := range pins {
+		if _, ok := newPins[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	sort.Strings(removed)
+
+	for _, name := range removed {
+		previous := pins[name]
+		delete(pins, name)
+		if !sendEvent(ctx, events, WatchEvent{Name: name, Previous: previous.version, Removed: true}) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// sendEvent sends the provided event, returning false if ctx is done
+// before it could be sent.
+func sendEvent(ctx context.Context, events chan<- WatchEvent, event WatchEvent) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case events <- event:
+		return true
+	}
+}
@@ -0,0 +1,508 @@
+// Copyright (C) 2024 vcs contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+// Package selfupdate implements updating a binary to the latest release
+// of the repository that it is built from. The latest version is
+// determined using the resolver package and the release asset for the
+// current platform is fetched using the releases package.
+package selfupdate
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/jaredallard/archives"
+	"github.com/jaredallard/cmdexec"
+	"github.com/jaredallard/vcs"
+	"github.com/jaredallard/vcs/internal/execerr"
+	"github.com/jaredallard/vcs/releases"
+	"github.com/jaredallard/vcs/resolver"
+)
+
+// ErrUpToDate is returned by [Update.Apply] when no newer version is
+// available.
+var ErrUpToDate = errors.New("already up to date")
+
+// archiveExtensions are the extensions of assets that are treated as
+// archives containing the binary.
+var archiveExtensions = []string{
+	".tar", ".tar.gz", ".tgz", ".tar.xz", ".txz", ".tar.bz2", ".tbz2", ".tar.zst", ".zip",
+}
+
+// DefaultChecksumAssetNames are the asset names used to find the
+// checksums of a release when [Options.ChecksumAssetNames] is not set.
+var DefaultChecksumAssetNames = []string{releases.ChecksumsFileName, "*_" + releases.ChecksumsFileName}
+
+// DefaultAssetNames returns the asset names used to find the asset for
+// the provided platform when [Options.AssetNames] is not set. These
+// match the default archive names created by goreleaser (e.g.,
+// "name_1.0.0_linux_amd64.tar.gz") for every supported archive format,
+// as well as un-archived binaries.
+func DefaultAssetNames(goos, goarch string) []string {
+	base := fmt.Sprintf("*_%s_%s", goos, goarch)
+
+	names := make([]string, 0, len(archiveExtensions)+2)
+	for _, ext := range archiveExtensions {
+		names = append(names, base+ext)
+	}
+	return append(names, base, base+".exe")
+}
+
+// Options contains options for checking for, and applying, updates.
+type Options struct {
+	// RepoURL is the URL of the repository that releases are published
+	// on. Required.
+	RepoURL string
+
+	// CurrentVersion is the version of the running binary (e.g.,
+	// "v1.2.3"). It must be a valid semantic version. Required.
+	CurrentVersion string
+
+	// Overrides are passed to the releases package, see
+	// [releases.FetchOptions].
+	Overrides []vcs.Override
+
+	// Fetcher, if set, is used to fetch release assets instead of the
+	// fetcher for the VCS provider of RepoURL, see
+	// [releases.FetchOptions].
+	Fetcher releases.Fetcher
+
+	// Resolver is used to determine the latest version. Defaults to a
+	// new resolver.
+	Resolver *resolver.Resolver
+
+	// Criteria are the criteria that the latest version must satisfy.
+	// Defaults to the newest version that is not a pre-release.
+	Criteria []*resolver.Criteria
+
+	// AssetNames are the names of the asset to download for the current
+	// platform, globs are supported. Defaults to [DefaultAssetNames] for
+	// the current GOOS and GOARCH.
+	//
+	// Assets that are tarballs (optionally compressed with gzip, xz,
+	// bzip2 or zstd) or zip archives are treated as archives that
+	// BinaryName is extracted from. All other assets are treated as the
+	// binary itself.
+	AssetNames []string
+
+	// BinaryName is the name of the binary inside of archives. Defaults
+	// to the name of Executable. On Windows, ".exe" is appended if it is
+	// missing.
+	BinaryName string
+
+	// Executable is the path to the binary to replace. Defaults to the
+	// currently running executable (see [os.Executable]).
+	Executable string
+
+	// ChecksumAssetNames are the names of the asset containing the
+	// checksums of all assets in a release (see [releases.Checksums]),
+	// globs are supported. Defaults to [DefaultChecksumAssetNames].
+	ChecksumAssetNames []string
+
+	// AllowMissingChecksums allows updating to a release that does not
+	// contain checksums. When not set, failing to fetch the checksums
+	// aborts the update. Any other error fetching the checksums always
+	// aborts the update. If checksums are found, the asset is always
+	// verified against them.
+	AllowMissingChecksums bool
+
+	// VerifySignature, if set, is called with the contents of the
+	// checksums asset before it is trusted. This can be used to verify a
+	// signature of it (e.g., by fetching "checksums.txt.sig" from the
+	// release with [releases.Fetch]). If it returns an error, the update
+	// is aborted. If set, the checksums asset is required.
+	VerifySignature func(ctx context.Context, u *Update, checksums []byte) error
+
+	// CosignKey, if set and VerifySignature is not, is the cosign public
+	// key (a path or KMS URI) used to verify the signature of the
+	// checksums asset, as created by [releases.Upload]. The signature is
+	// fetched from the "<checksums asset>.sig" asset of the release. If
+	// set, the checksums asset and its signature are required.
+	CosignKey string
+
+	// Cosign is the path to the cosign binary used to verify signatures
+	// with CosignKey. Defaults to "cosign".
+	Cosign string
+
+	// Validate, if set, is called with the path to the executable once
+	// it has been replaced (e.g., to run "<path> --version"). If it
+	// returns an error, the previous executable is restored.
+	Validate func(ctx context.Context, path string) error
+}
+
+// Update is an update returned by [Check].
+type Update struct {
+	// Current is the current version.
+	Current *semver.Version
+
+	// Latest is the latest version that satisfies the criteria.
+	Latest *resolver.Version
+
+	// Available is true if Latest is newer than Current.
+	Available bool
+
+	// opts are the options that the update was checked with.
+	opts Options
+}
+
+// Check determines the latest version satisfying the provided options
+// and returns it as an [Update]. The update can be applied with
+// [Update.Apply].
+func Check(ctx context.Context, opts *Options) (*Update, error) {
+	if opts == nil {
+		return nil, fmt.Errorf("opts is nil")
+	}
+
+	if opts.RepoURL == "" {
+		return nil, fmt.Errorf("repo url is required")
+	}
+
+	current, err := semver.NewVersion(opts.CurrentVersion)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse current version %q: %w", opts.CurrentVersion, err)
+	}
+
+	r := opts.Resolver
+	if r == nil {
+		r = resolver.NewResolver()
+	}
+
+	criteria := opts.Criteria
+	if len(criteria) == 0 {
+		criteria = []*resolver.Criteria{{Constraint: "*"}}
+	}
+
+	latest, err := r.Resolve(ctx, opts.RepoURL, criteria...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve latest version: %w", err)
+	}
+	if latest.Tag == "" {
+		return nil, fmt.Errorf("latest version %s is not a release", latest)
+	}
+
+	latestSV, err := semver.NewVersion(latest.Tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse latest version %q: %w", latest.Tag, err)
+	}
+
+	return &Update{
+		Current:   current,
+		Latest:    latest,
+		Available: latestSV.GreaterThan(current),
+		opts:      *opts,
+	}, nil
+}
+
+// Run checks for an update and applies it if one is available. The
+// returned update can be used to determine if an update was applied
+// (see [Update.Available]).
+func Run(ctx context.Context, opts *Options) (*Update, error) {
+	u, err := Check(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if !u.Available {
+		return u, nil
+	}
+
+	return u, u.Apply(ctx)
+}
+
+// Apply downloads the asset for the latest version, verifies it and
+// replaces the executable with it. If no newer version is available,
+// [ErrUpToDate] is returned.
+//
+// The new executable is written next to the current one before
+// atomically replacing it, keeping a backup of the current executable
+// until the update has been validated (see [Options.Validate]). If
+// replacing or validating fails, the backup is restored. On Windows, the
+// backup of a running executable cannot be removed and is left behind
+// as "<executable>.old" until the next update.
+func (u *Update) Apply(ctx context.Context) error {
+	if !u.Available {
+		return ErrUpToDate
+	}
+
+	exe := u.opts.Executable
+	if exe == "" {
+		var err error
+		exe, err = os.Executable()
+		if err != nil {
+			return fmt.Errorf("failed to determine executable: %w", err)
+		}
+	}
+	exe, err := filepath.EvalSymlinks(exe)
+	if err != nil {
+		return fmt.Errorf("failed to resolve executable: %w", err)
+	}
+
+	exeInfo, err := os.Stat(exe)
+	if err != nil {
+		return fmt.Errorf("failed to stat executable: %w", err)
+	}
+
+	checksums, err := u.fetchChecksums(ctx)
+	if err != nil {
+		return err
+	}
+
+	assetNames := u.opts.AssetNames
+	if len(assetNames) == 0 {
+		assetNames = DefaultAssetNames(runtime.GOOS, runtime.GOARCH)
+	}
+
+	rc, fi, err := releases.Fetch(ctx, &releases.FetchOptions{
+		Overrides:  u.opts.Overrides,
+		Fetcher:    u.opts.Fetcher,
+		RepoURL:    u.opts.RepoURL,
+		Tag:        u.Latest.Tag,
+		AssetNames: assetNames,
+	})
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	// Write everything next to the executable to ensure that it's on the
+	// same filesystem, otherwise it can't be renamed into place.
+	dir := filepath.Dir(exe)
+	asset, err := os.CreateTemp(dir, "."+filepath.Base(exe)+"-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(asset.Name())
+	defer asset.Close()
+
+	if checksums != nil {
+		err = checksums.Verify(fi.Name(), io.TeeReader(rc, asset))
+	} else {
+		_, err = io.Copy(asset, rc)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to download asset %s: %w", fi.Name(), err)
+	}
+
+	binaryName := u.opts.BinaryName
+	if binaryName == "" {
+		binaryName = filepath.Base(exe)
+	}
+	if runtime.GOOS == "windows" && !strings.HasSuffix(binaryName, ".exe") {
+		binaryName += ".exe"
+	}
+
+	bin, err := extract(asset, fi.Name(), binaryName)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(bin)
+
+	if err := os.Chmod(bin, exeInfo.Mode().Perm()); err != nil {
+		return fmt.Errorf("failed to set permissions on new executable: %w", err)
+	}
+
+	return replace(ctx, exe, bin, u.opts.Validate)
+}
+
+// extract returns the path to the binary contained in the provided
+// asset. If the asset is an archive, binaryName is extracted from the
+// root of it into a new temporary file next to the asset. Otherwise,
+// the asset is the binary and its path is returned.
+func extract(asset *os.File, assetName, binaryName string) (string, error) {
+	ext := archives.Ext(assetName)
+	if !slices.Contains(archiveExtensions, ext) {
+		return asset.Name(), asset.Close()
+	}
+
+	if _, err := asset.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("failed to read asset %s: %w", assetName, err)
+	}
+
+	a, err := archives.Open(asset, archives.OpenOptions{Extension: ext})
+	if err != nil {
+		return "", fmt.Errorf("failed to open archive %s: %w", assetName, err)
+	}
+	defer a.Close()
+
+	r, err := archives.Pick(a, archives.PickFilterByName(binaryName))
+	if err != nil {
+		return "", fmt.Errorf("failed to extract %s from %s: %w", binaryName, assetName, err)
+	}
+
+	bin, err := os.CreateTemp(filepath.Dir(asset.Name()), "."+binaryName+"-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer bin.Close()
+
+	if _, err := io.Copy(bin, r); err != nil {
+		os.Remove(bin.Name())
+		return "", fmt.Errorf("failed to extract %s from %s: %w", binaryName, assetName, err)
+	}
+
+	return bin.Name(), bin.Close()
+}
+
+// fetchChecksums fetches, and verifies the signature of, the checksums
+// of the latest release. If the checksums are missing and that is
+// allowed, nil is returned.
+func (u *Update) fetchChecksums(ctx context.Context) (releases.Checksums, error) {
+	assetNames := u.opts.ChecksumAssetNames
+	if len(assetNames) == 0 {
+		assetNames = DefaultChecksumAssetNames
+	}
+
+	verify := u.opts.VerifySignature != nil || u.opts.CosignKey != ""
+	rc, fi, err := releases.Fetch(ctx, &releases.FetchOptions{
+		Overrides:  u.opts.Overrides,
+		Fetcher:    u.opts.Fetcher,
+		RepoURL:    u.opts.RepoURL,
+		Tag:        u.Latest.Tag,
+		AssetNames: assetNames,
+	})
+	if err != nil {
+		if errors.Is(err, releases.ErrAssetNotFound) && u.opts.AllowMissingChecksums && !verify {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("failed to fetch checksums: %w", err)
+	}
+	defer rc.Close()
+
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checksums: %w", err)
+	}
+
+	if u.opts.VerifySignature != nil {
+		err = u.opts.VerifySignature(ctx, u, b)
+	} else if u.opts.CosignKey != "" {
+		err = u.verifyCosignSignature(ctx, fi.Name(), b)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify checksums signature: %w", err)
+	}
+
+	return releases.ParseChecksums(bytes.NewReader(b))
+}
+
+// verifyCosignSignature verifies the checksums asset, named name, with
+// cosign using the signature from the "<name>.sig" asset of the latest
+// release.
+func (u *Update) verifyCosignSignature(ctx context.Context, name string, checksums []byte) error {
+	rc, _, err := releases.Fetch(ctx, &releases.FetchOptions{
+		Overrides: u.opts.Overrides,
+		Fetcher:   u.opts.Fetcher,
+		RepoURL:   u.opts.RepoURL,
+		Tag:       u.Latest.Tag,
+		AssetName: name + ".sig",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to fetch signature: %w", err)
+	}
+	defer rc.Close()
+
+	sig, err := io.ReadAll(rc)
+	if err != nil {
+		return fmt.Errorf("failed to read signature: %w", err)
+	}
+
+	// cosign only verifies files, so write both of them to disk.
+	dir, err := os.MkdirTemp("", "vcs-selfupdate-")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	blobPath := filepath.Join(dir, filepath.Base(name))
+	sigPath := blobPath + ".sig"
+	if err := os.WriteFile(blobPath, checksums, 0o600); err != nil {
+		return fmt.Errorf("failed to write checksums: %w", err)
+	}
+	if err := os.WriteFile(sigPath, sig, 0o600); err != nil {
+		return fmt.Errorf("failed to write signature: %w", err)
+	}
+
+	cosign := u.opts.Cosign
+	if cosign == "" {
+		cosign = "cosign"
+	}
+
+	args := []string{"verify-blob", "--key", u.opts.CosignKey, "--signature", sigPath, blobPath}
+	if _, err := cmdexec.CommandContext(ctx, cosign, args...).Output(); err != nil {
+		return fmt.Errorf("cosign failed to verify %s: %w", name, execerr.From(err))
+	}
+
+	return nil
+}
+
+// replace atomically replaces exe with bin, keeping a backup of exe
+// until validate (if set) has succeeded. If anything fails, exe is
+// restored from the backup.
+func replace(ctx context.Context, exe, bin string, validate func(context.Context, string) error) error {
+	backup := exe + ".old"
+
+	// Remove any leftovers from a previous update.
+	if err := os.Remove(backup); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove previous backup: %w", err)
+	}
+
+	// Prefer a hard link so that exe never stops existing. On Windows, a
+	// running executable can't be replaced but it can be renamed, so it
+	// must be moved out of the way instead.
+	if runtime.GOOS == "windows" || os.Link(exe, backup) != nil {
+		if err := os.Rename(exe, backup); err != nil {
+			return fmt.Errorf("failed to back up executable: %w", err)
+		}
+	}
+
+	if err := os.Rename(bin, exe); err != nil {
+		return rollback(exe, backup, fmt.Errorf("failed to replace executable: %w", err))
+	}
+
+	if validate != nil {
+		if err := validate(ctx, exe); err != nil {
+			return rollback(exe, backup, fmt.Errorf("failed to validate new executable: %w", err))
+		}
+	}
+
+	// Not being able to remove the backup is expected on Windows, so
+	// this is intentionally best effort.
+	os.Remove(backup)
+
+	return nil
+}
+
+// rollback restores exe from backup, returning err annotated with the
+// outcome.
+func rollback(exe, backup string, err error) error {
+	if rerr := os.Rename(backup, exe); rerr != nil {
+		return fmt.Errorf("%w (failed to restore backup %s: %w)", err, backup, rerr)
+	}
+
+	return fmt.Errorf("%w (rolled back)", err)
+}
@@ -0,0 +1,271 @@
+package selfupdate_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/jaredallard/vcs/internal/testutil"
+	"github.com/jaredallard/vcs/releases"
+	"github.com/jaredallard/vcs/selfupdate"
+	"github.com/jaredallard/vcs/token"
+	"gotest.tools/v3/assert"
+)
+
+// newRelease returns a fetcher serving a release containing a tarball
+// of a "tool" binary with the provided contents for the current
+// platform, as well as its checksums.
+func newRelease(t *testing.T, contents string) *testutil.FakeFetcher {
+	t.Helper()
+
+	var buf bytes.Buffer
+	gzw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gzw)
+	assert.NilError(t, tw.WriteHeader(&tar.Header{Name: "tool", Mode: 0o755, Size: int64(len(contents))}))
+	_, err := tw.Write([]byte(contents))
+	assert.NilError(t, err)
+	assert.NilError(t, tw.Close())
+	assert.NilError(t, gzw.Close())
+
+	name := fmt.Sprintf("tool_1.1.0_%s_%s.tar.gz", runtime.GOOS, runtime.GOARCH)
+	checksums := releases.Checksums{}
+	assert.NilError(t, checksums.Add(name, bytes.NewReader(buf.Bytes())))
+
+	var checksumsBuf bytes.Buffer
+	_, err = checksums.WriteTo(&checksumsBuf)
+	assert.NilError(t, err)
+
+	return &testutil.FakeFetcher{Assets: map[string][]byte{
+		name:                       buf.Bytes(),
+		releases.ChecksumsFileName: checksumsBuf.Bytes(),
+	}}
+}
+
+// newTestOptions creates a repository with a v1.0.0 and v1.1.0 tag and
+// an executable "tool" to update, returning options for them.
+func newTestOptions(t *testing.T, f *testutil.FakeFetcher) *selfupdate.Options {
+	t.Helper()
+
+	repo := testutil.NewLocalRepo(t,
+		[]string{"commit", "--allow-empty", "-m", "initial"},
+		[]string{"tag", "v1.0.0"},
+		[]string{"commit", "--allow-empty", "-m", "next"},
+		[]string{"tag", "v1.1.0"},
+	)
+
+	exe := filepath.Join(t.TempDir(), "tool")
+	assert.NilError(t, os.WriteFile(exe, []byte("old"), 0o755))
+
+	return &selfupdate.Options{
+		RepoURL:        repo,
+		CurrentVersion: "v1.0.0",
+		Fetcher:        f,
+		Executable:     exe,
+	}
+}
+
+// failingFetcher is a [testutil.FakeFetcher] that fails to fetch any
+// asset with err.
+type failingFetcher struct {
+	*testutil.FakeFetcher
+	err error
+}
+
+// Fetch implements [releases.Fetcher].
+func (f *failingFetcher) Fetch(context.Context, *token.Token, *releases.FetchOptions) (io.ReadCloser, os.FileInfo, error) {
+	return nil, nil, f.err
+}
+
+// readExecutable returns the contents of the executable and the names
+// of all files next to it.
+func readExecutable(t *testing.T, exe string) (string, []string) {
+	t.Helper()
+
+	b, err := os.ReadFile(exe)
+	assert.NilError(t, err)
+
+	entries, err := os.ReadDir(filepath.Dir(exe))
+	assert.NilError(t, err)
+
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+
+	return string(b), names
+}
+
+func TestRunUpdatesExecutable(t *testing.T) {
+	ctx := context.Background()
+	opts := newTestOptions(t, newRelease(t, "new"))
+
+	u, err := selfupdate.Run(ctx, opts)
+	assert.NilError(t, err)
+	assert.Equal(t, u.Available, true)
+	assert.Equal(t, u.Latest.Tag, "v1.1.0")
+
+	contents, files := readExecutable(t, opts.Executable)
+	assert.Equal(t, contents, "new")
+	assert.DeepEqual(t, files, []string{"tool"})
+
+	fi, err := os.Stat(opts.Executable)
+	assert.NilError(t, err)
+	assert.Equal(t, fi.Mode().Perm(), fs.FileMode(0o755))
+
+	// Nothing to do once up to date.
+	opts.CurrentVersion = "v1.1.0"
+	u, err = selfupdate.Check(ctx, opts)
+	assert.NilError(t, err)
+	assert.Equal(t, u.Available, false)
+	assert.ErrorIs(t, u.Apply(ctx), selfupdate.ErrUpToDate)
+}
+
+func TestRunVerifiesChecksums(t *testing.T) {
+	ctx := context.Background()
+	f := newRelease(t, "new")
+	f.Assets[releases.ChecksumsFileName] = []byte(fmt.Sprintf(
+		"%s  tool_1.1.0_%s_%s.tar.gz\n", strings.Repeat("0", 64), runtime.GOOS, runtime.GOARCH,
+	))
+	opts := newTestOptions(t, f)
+
+	_, err := selfupdate.Run(ctx, opts)
+	assert.ErrorIs(t, err, releases.ErrChecksumMismatch)
+
+	contents, files := readExecutable(t, opts.Executable)
+	assert.Equal(t, contents, "old")
+	assert.DeepEqual(t, files, []string{"tool"})
+
+	// Signatures are verified before checksums are trusted.
+	opts.VerifySignature = func(context.Context, *selfupdate.Update, []byte) error {
+		return errors.New("bad signature")
+	}
+	_, err = selfupdate.Run(ctx, opts)
+	assert.ErrorContains(t, err, "bad signature")
+
+	// Missing checksums are only allowed if asked for.
+	delete(f.Assets, releases.ChecksumsFileName)
+	opts.VerifySignature = nil
+	_, err = selfupdate.Run(ctx, opts)
+	assert.ErrorContains(t, err, "failed to fetch checksums")
+
+	opts.AllowMissingChecksums = true
+	_, err = selfupdate.Run(ctx, opts)
+	assert.NilError(t, err)
+
+	contents, _ = readExecutable(t, opts.Executable)
+	assert.Equal(t, contents, "new")
+}
+
+func TestRunVerifiesCosignSignature(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake cosign is a shell script")
+	}
+
+	ctx := context.Background()
+	f := newRelease(t, "new")
+	opts := newTestOptions(t, f)
+	opts.CosignKey = "cosign.pub"
+
+	// The fake cosign considers a signature valid if it is "signed "
+	// followed by the contents of the blob.
+	opts.Cosign = filepath.Join(t.TempDir(), "cosign")
+	script := `#!/bin/sh
+[ "$1" = "verify-blob" ] || exit 1
+while [ $# -gt 1 ]; do
+  case "$1" in
+  --key) key="$2" ;;
+  --signature) sig="$2" ;;
+  esac
+  shift
+done
+[ "$key" = "cosign.pub" ] && [ "$(cat "$sig")" = "signed $(cat "$1")" ] && exit 0
+echo "invalid signature" >&2
+exit 1
+`
+	assert.NilError(t, os.WriteFile(opts.Cosign, []byte(script), 0o700))
+
+	// Signatures are required.
+	_, err := selfupdate.Run(ctx, opts)
+	assert.ErrorContains(t, err, "failed to fetch signature")
+
+	f.Assets[releases.ChecksumsFileName+".sig"] = []byte("signed something else")
+	_, err = selfupdate.Run(ctx, opts)
+	assert.ErrorContains(t, err, "invalid signature")
+
+	contents, _ := readExecutable(t, opts.Executable)
+	assert.Equal(t, contents, "old")
+
+	f.Assets[releases.ChecksumsFileName+".sig"] = append([]byte("signed "), f.Assets[releases.ChecksumsFileName]...)
+	_, err = selfupdate.Run(ctx, opts)
+	assert.NilError(t, err)
+
+	contents, _ = readExecutable(t, opts.Executable)
+	assert.Equal(t, contents, "new")
+}
+
+func TestDefaultAssetNamesIncludeArchives(t *testing.T) {
+	names := selfupdate.DefaultAssetNames("linux", "amd64")
+	for _, name := range []string{
+		"tool_1.0.0_linux_amd64.tar.gz",
+		"tool_1.0.0_linux_amd64.tar.xz",
+		"tool_1.0.0_linux_amd64.tar.zst",
+		"tool_1.0.0_linux_amd64.zip",
+		"tool_1.0.0_linux_amd64",
+	} {
+		matched := false
+		for _, pattern := range names {
+			if ok, _ := filepath.Match(pattern, name); ok {
+				matched = true
+			}
+		}
+		assert.Assert(t, matched, "%s is not matched by %v", name, names)
+	}
+}
+
+func TestRunOnlyAllowsChecksumsThatAreMissing(t *testing.T) {
+	ctx := context.Background()
+	errUnavailable := errors.New("service unavailable")
+	f := newRelease(t, "new")
+	opts := newTestOptions(t, f)
+	opts.Fetcher = &failingFetcher{FakeFetcher: f, err: errUnavailable}
+	opts.AllowMissingChecksums = true
+
+	_, err := selfupdate.Run(ctx, opts)
+	assert.ErrorIs(t, err, errUnavailable)
+	assert.ErrorContains(t, err, "failed to fetch checksums")
+
+	contents, _ := readExecutable(t, opts.Executable)
+	assert.Equal(t, contents, "old")
+}
+
+func TestRunRollsBackOnFailedValidation(t *testing.T) {
+	ctx := context.Background()
+	opts := newTestOptions(t, newRelease(t, "new"))
+
+	var validated string
+	opts.Validate = func(_ context.Context, path string) error {
+		b, err := os.ReadFile(path)
+		assert.NilError(t, err)
+		validated = string(b)
+		return errors.New("broken binary")
+	}
+
+	_, err := selfupdate.Run(ctx, opts)
+	assert.ErrorContains(t, err, "broken binary")
+	assert.ErrorContains(t, err, "rolled back")
+	assert.Equal(t, validated, "new")
+
+	contents, files := readExecutable(t, opts.Executable)
+	assert.Equal(t, contents, "old")
+	assert.DeepEqual(t, files, []string{"tool"})
+}
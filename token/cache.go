@@ -19,14 +19,44 @@
 package token
 
 import (
+	"errors"
+	"fmt"
 	"sync"
 
 	"github.com/jaredallard/vcs"
 	"github.com/jaredallard/vcs/token/internal/shared"
 )
 
+// ErrInsecureCache is returned when the configured [Policy] requires a
+// secure cache backend, but the cache backend is not secure.
+var ErrInsecureCache = errors.New("token cache backend is not secure, refusing to cache tokens")
+
+// CacheBackend is a cache of tokens used by [Fetch]. Implementations
+// must be safe for concurrent use.
+type CacheBackend interface {
+	// Get returns a token from the cache if it exists.
+	Get(provider vcs.Provider) (*shared.Token, bool)
+
+	// Set sets a token in the cache.
+	Set(provider vcs.Provider, token *shared.Token)
+
+	// Secure returns true if the backend never persists tokens in
+	// plaintext, e.g., because it only stores them in memory or
+	// encrypts them at rest.
+	Secure() bool
+}
+
+// Policy contains policies that are enforced by this package.
+type Policy struct {
+	// RequireSecureCache refuses to use cache backends that are not
+	// secure (see [CacheBackend.Secure]). When set, [SetCacheBackend]
+	// and [Fetch] (unless [Options.UseGlobalCache] is false) return
+	// [ErrInsecureCache] instead of using an insecure backend.
+	RequireSecureCache bool
+}
+
 // tokenCache is a cache of tokens that have been fetched from the
-// user's machine.
+// user's machine. It is only ever stored in memory.
 type tokenCache struct {
 	// tokensMu is a mutex to protect the tokens map.
 	tokensMu sync.RWMutex
@@ -35,6 +65,12 @@ type tokenCache struct {
 	tokens map[vcs.Provider]*shared.Token
 }
 
+// NewMemoryCacheBackend returns a new [CacheBackend] that only stores
+// tokens in memory. This is the default cache backend.
+func NewMemoryCacheBackend() CacheBackend {
+	return &tokenCache{tokens: make(map[vcs.Provider]*shared.Token)}
+}
+
 // Get returns a token from the cache if it exists.
 func (c *tokenCache) Get(provider vcs.Provider) (*shared.Token, bool) {
 	c.tokensMu.RLock()
@@ -52,5 +88,63 @@ func (c *tokenCache) Set(provider vcs.Provider, token *shared.Token) {
 	c.tokens[provider] = token
 }
 
-// cache is the global token cache.
-var cache = &tokenCache{tokens: make(map[vcs.Provider]*shared.Token)}
+// Secure implements [CacheBackend.Secure]. Tokens are only stored in
+// memory, so this is always true.
+func (c *tokenCache) Secure() bool {
+	return true
+}
+
+// This block contains the global cache and policy.
+var (
+	// cache is the global token cache.
+	cache = NewMemoryCacheBackend()
+
+	// policy is the global policy.
+	policy Policy
+
+	// globalMu protects cache and policy.
+	globalMu sync.RWMutex
+)
+
+// SetCacheBackend replaces the global cache backend used by [Fetch].
+// If the current [Policy] requires a secure cache and the backend is
+// not secure, [ErrInsecureCache] is returned and the backend is not
+// used. To disable caching, set [Options.UseGlobalCache] instead.
+func SetCacheBackend(backend CacheBackend) error {
+	if backend == nil {
+		return fmt.Errorf("cache backend must not be nil")
+	}
+
+	globalMu.Lock()
+	defer globalMu.Unlock()
+
+	if policy.RequireSecureCache && !backend.Secure() {
+		return ErrInsecureCache
+	}
+
+	cache = backend
+	return nil
+}
+
+// SetPolicy sets the global policy enforced by this package. Policies
+// are verified whenever a token is fetched, so a policy applies to
+// cache backends that were configured before it was set.
+func SetPolicy(p Policy) {
+	globalMu.Lock()
+	defer globalMu.Unlock()
+
+	policy = p
+}
+
+// getCache returns the global cache backend, verifying that it is
+// allowed by the global policy.
+func getCache() (CacheBackend, error) {
+	globalMu.RLock()
+	defer globalMu.RUnlock()
+
+	if policy.RequireSecureCache && !cache.Secure() {
+		return nil, ErrInsecureCache
+	}
+
+	return cache, nil
+}
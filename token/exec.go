@@ -0,0 +1,196 @@
+// Copyright (C) 2024 vcs contributors
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public
+// License along with this program. If not, see
+// <https://www.gnu.org/licenses/>.
+//
+// SPDX-License-Identifier: LGPL-3.0
+
+package token
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"sort"
+
+	"github.com/jaredallard/cmdexec"
+	"github.com/jaredallard/vcs"
+)
+
+// _ ensures that [Cmd] implements [cmdexec.Cmd].
+var _ cmdexec.Cmd = &Cmd{}
+
+// redacted replaces the token in everything returned or written by a
+// [Cmd]. A fixed value is used so that nothing about the token (e.g.,
+// its length or prefix) is revealed.
+const redacted = "[REDACTED]"
+
+// Cmd is a [cmdexec.Cmd] that has a token injected into its
+// environment and redacts the token from everything it returns or
+// writes (output, errors and the command line). Create one with
+// [Command].
+type Cmd struct {
+	cmdexec.Cmd
+
+	// t is the token being injected and redacted.
+	t *Token
+
+	// env contains the environment variables (KEY=VALUE) containing the
+	// token.
+	env []string
+
+	// writers contains all writers that were wrapped for redaction, so
+	// that they can be flushed once the command has finished.
+	writers []*redactingWriter
+}
+
+// Command returns a [Cmd] that runs the provided command with the
+// token's environment variables (see [Token.Env]) set on top of the
+// current environment.
+func Command(ctx context.Context, t *Token, vcsp vcs.Provider, name string, arg ...string) *Cmd {
+	c := &Cmd{Cmd: cmdexec.CommandContext(ctx, name, arg...), t: t}
+	for k, v := range t.Env(vcsp) {
+		c.env = append(c.env, k+"="+v)
+	}
+	sort.Strings(c.env)
+
+	c.SetEnviron(os.Environ())
+	return c
+}
+
+// redact replaces all occurrences of the token in b with [redacted].
+func (c *Cmd) redact(b []byte) []byte {
+	if c.t.IsUnauthenticated() {
+		return b
+	}
+
+	return bytes.ReplaceAll(b, []byte(c.t.Value), []byte(redacted))
+}
+
+// finish flushes all wrapped writers and redacts the stderr contained
+// in err, if any.
+func (c *Cmd) finish(err error) error {
+	for _, w := range c.writers {
+		if ferr := w.Flush(); ferr != nil && err == nil {
+			err = ferr
+		}
+	}
+
+	var execErr *exec.ExitError
+	if errors.As(err, &execErr) {
+		execErr.Stderr = c.redact(execErr.Stderr)
+	}
+
+	return err
+}
+
+// Output implements [cmdexec.Cmd.Output].
+func (c *Cmd) Output() ([]byte, error) {
+	b, err := c.Cmd.Output()
+	return c.redact(b), c.finish(err)
+}
+
+// CombinedOutput implements [cmdexec.Cmd.CombinedOutput].
+func (c *Cmd) CombinedOutput() ([]byte, error) {
+	b, err := c.Cmd.CombinedOutput()
+	return c.redact(b), c.finish(err)
+}
+
+// Run implements [cmdexec.Cmd.Run].
+func (c *Cmd) Run() error {
+	return c.finish(c.Cmd.Run())
+}
+
+// String implements [cmdexec.Cmd.String].
+func (c *Cmd) String() string {
+	return string(c.redact([]byte(c.Cmd.String())))
+}
+
+// SetEnviron implements [cmdexec.Cmd.SetEnviron]. The token's
+// environment variables are always appended to env.
+func (c *Cmd) SetEnviron(env []string) {
+	c.Cmd.SetEnviron(append(slices.Clone(env), c.env...))
+}
+
+// SetStdout implements [cmdexec.Cmd.SetStdout].
+func (c *Cmd) SetStdout(w io.Writer) {
+	c.Cmd.SetStdout(c.wrap(w))
+}
+
+// SetStderr implements [cmdexec.Cmd.SetStderr].
+func (c *Cmd) SetStderr(w io.Writer) {
+	c.Cmd.SetStderr(c.wrap(w))
+}
+
+// UseOSStreams implements [cmdexec.Cmd.UseOSStreams].
+func (c *Cmd) UseOSStreams(stdin bool) {
+	c.SetStdout(os.Stdout)
+	c.SetStderr(os.Stderr)
+	if stdin {
+		c.SetStdin(os.Stdin)
+	}
+}
+
+// wrap returns a writer that redacts the token from everything written
+// to w.
+func (c *Cmd) wrap(w io.Writer) io.Writer {
+	if w == nil {
+		return nil
+	}
+
+	rw := &redactingWriter{w: w, redact: c.redact}
+	c.writers = append(c.writers, rw)
+	return rw
+}
+
+// redactingWriter is an [io.Writer] that redacts data before writing
+// it to the underlying writer. Data is buffered per-line to ensure that
+// a token split across multiple writes is still redacted.
+type redactingWriter struct {
+	w      io.Writer
+	redact func([]byte) []byte
+	buf    []byte
+}
+
+// Write implements [io.Writer].
+func (rw *redactingWriter) Write(p []byte) (int, error) {
+	rw.buf = append(rw.buf, p...)
+
+	i := bytes.LastIndexByte(rw.buf, '\n')
+	if i == -1 {
+		return len(p), nil
+	}
+
+	if _, err := rw.w.Write(rw.redact(rw.buf[:i+1])); err != nil {
+		return 0, err
+	}
+	rw.buf = slices.Clone(rw.buf[i+1:])
+
+	return len(p), nil
+}
+
+// Flush writes any buffered data to the underlying writer.
+func (rw *redactingWriter) Flush() error {
+	if len(rw.buf) == 0 {
+		return nil
+	}
+
+	_, err := rw.w.Write(rw.redact(rw.buf))
+	rw.buf = nil
+	return err
+}
@@ -20,7 +20,11 @@
 package github
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"os"
 	"strings"
 
 	"github.com/jaredallard/cmdexec"
@@ -57,3 +61,68 @@ func (p *GHProvider) Token() (*shared.Token, error) {
 		Value:  token,
 	}, nil
 }
+
+// Permissions returns the permissions of a GITHUB_TOKEN provided by
+// Github Actions for the repository the workflow is running in, using
+// the installation repositories endpoint. The repository level
+// permissions returned by the API are mapped to the "contents"
+// permission, no other permissions are determined.
+//
+// This is a guess: the API describes the access of the Github Actions
+// installation to the repository, which may not match the permissions
+// granted to the token by the workflow's "permissions" block.
+func Permissions(ctx context.Context, t *shared.Token) (map[string]string, error) {
+	apiURL := os.Getenv("GITHUB_API_URL")
+	if apiURL == "" {
+		apiURL = "https://api.github.com"
+	}
+	repository := os.Getenv("GITHUB_REPOSITORY")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		strings.TrimSuffix(apiURL, "/")+"/installation/repositories?per_page=100", http.NoBody,
+	)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+t.Value)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get installation repositories: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get installation repositories: unexpected status %s", resp.Status)
+	}
+
+	var body struct {
+		Repositories []struct {
+			FullName    string          `json:"full_name"`
+			Permissions map[string]bool `json:"permissions"`
+		} `json:"repositories"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("failed to decode installation repositories: %w", err)
+	}
+
+	for _, repo := range body.Repositories {
+		if !strings.EqualFold(repo.FullName, repository) {
+			continue
+		}
+
+		perms := make(map[string]string)
+		switch {
+		case repo.Permissions["push"]:
+			perms["contents"] = "write"
+		case repo.Permissions["pull"]:
+			perms["contents"] = "read"
+		default:
+			perms["contents"] = "none"
+		}
+		return perms, nil
+	}
+
+	return nil, fmt.Errorf("repository %s not accessible by token", repository)
+}
@@ -30,8 +30,8 @@ import (
 
 // Contains the different types of tokens that can be retrieved.
 const (
-	TokenTypeJob = "job"
-	TokenTypePAT = "pat"
+	TokenTypeJob = shared.GitlabTokenTypeJob
+	TokenTypePAT = shared.GitlabTokenTypePAT
 )
 
 // Providers is a list of providers that can be used to retrieve a
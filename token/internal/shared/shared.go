@@ -21,10 +21,32 @@
 package shared
 
 import (
+	"fmt"
+	"maps"
 	"strings"
 	"time"
+
+	"github.com/jaredallard/vcs"
+)
+
+// Contains the different types of Gitlab tokens. These are re-exported
+// by the gitlab package, which can't be imported here.
+const (
+	GitlabTokenTypeJob = "job"
+	GitlabTokenTypePAT = "pat"
 )
 
+// envVars contains the canonical environment variables that tools
+// expect a token to be provided in, by VCS provider and token type.
+var envVars = map[vcs.Provider]map[string][]string{
+	vcs.ProviderGithub: {"": {"GITHUB_TOKEN", "GH_TOKEN"}},
+	vcs.ProviderGitlab: {
+		"":                 {"GITLAB_TOKEN"},
+		GitlabTokenTypePAT: {"GITLAB_TOKEN"},
+		GitlabTokenTypeJob: {"CI_JOB_TOKEN"},
+	},
+}
+
 // Token is a VCS token that can be used for API access.
 //
 // Do not use the 'shared.Token' type, instead use [token.Token] which
@@ -45,6 +67,15 @@ type Token struct {
 	// Type is the type of the token, this is set depending on the
 	// provider that provided the token.
 	Type string
+
+	// Permissions contains the permissions granted to the token, keyed
+	// by permission name (e.g., "contents") with their access level
+	// ("none", "read" or "write"). Only permissions that could be
+	// determined are present. This is nil if no permissions could be
+	// determined, which is the case for all tokens except the
+	// GITHUB_TOKEN provided by Github Actions. Even then, permissions
+	// are inferred and may not match what the token was granted.
+	Permissions map[string]string
 }
 
 // IsUnauthenticated returns true if the token is empty.
@@ -73,7 +104,52 @@ func (t *Token) Clone() *Token {
 		Source:    t.Source,
 		Value:     t.Value,
 		Type:      t.Type,
+
+		Permissions: maps.Clone(t.Permissions),
+	}
+}
+
+// RequirePermission returns an error if the token is known to not have
+// the provided permission at the provided access level ("read" or
+// "write", the latter implying the former). If the token's permission
+// is unknown, nil is returned.
+//
+// This is meant to be used to fail early with a clear error message
+// (e.g., "missing contents:write permission") instead of an opaque
+// error from the VCS provider.
+func (t *Token) RequirePermission(name, level string) error {
+	granted, ok := t.Permissions[name]
+	if !ok {
+		return nil
+	}
+
+	switch granted {
+	case "write":
+		return nil
+	case "read":
+		if level == "read" {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("missing %s:%s permission", name, level)
+}
+
+// Env returns the canonical environment variables (e.g.,
+// GITHUB_TOKEN and GH_TOKEN for Github) containing the token for the
+// provided VCS provider. This is meant to be used when passing the
+// token to subprocesses (e.g., gh or glab). If the token is
+// unauthenticated, or the provider is unknown, an empty map is returned.
+func (t *Token) Env(vcsp vcs.Provider) map[string]string {
+	env := make(map[string]string)
+	if t.IsUnauthenticated() {
+		return env
+	}
+
+	for _, name := range envVars[vcsp][t.Type] {
+		env[name] = t.Value
 	}
+	return env
 }
 
 // Provider is an interface for VCS providers to implement to provide a
@@ -77,3 +77,36 @@ func TestIsUnauthenticatedDetectsEmptyToken(t *testing.T) {
 
 	assert.Assert(t, originalToken.IsUnauthenticated(), "expected token to be unauthenticated")
 }
+
+func TestEnvReturnsCanonicalEnvVars(t *testing.T) {
+	tok := &shared.Token{Value: "token"}
+	assert.DeepEqual(t, tok.Env(vcs.ProviderGithub), map[string]string{
+		"GITHUB_TOKEN": "token",
+		"GH_TOKEN":     "token",
+	})
+	assert.DeepEqual(t, tok.Env(vcs.ProviderGitlab), map[string]string{
+		"GITLAB_TOKEN": "token",
+	})
+
+	jobToken := &shared.Token{Value: "token", Type: shared.GitlabTokenTypeJob}
+	assert.DeepEqual(t, jobToken.Env(vcs.ProviderGitlab), map[string]string{
+		"CI_JOB_TOKEN": "token",
+	})
+
+	assert.DeepEqual(t, (&shared.Token{}).Env(vcs.ProviderGithub), map[string]string{})
+}
+
+func TestRequirePermission(t *testing.T) {
+	unknown := &shared.Token{Value: "token"}
+	assert.NilError(t, unknown.RequirePermission("contents", "write"))
+
+	write := &shared.Token{Value: "token", Permissions: map[string]string{"contents": "write"}}
+	assert.NilError(t, write.RequirePermission("contents", "read"))
+	assert.NilError(t, write.RequirePermission("contents", "write"))
+
+	// Permissions that were never determined are unknown.
+	assert.NilError(t, write.RequirePermission("packages", "write"))
+
+	none := &shared.Token{Value: "token", Permissions: map[string]string{"contents": "none"}}
+	assert.Error(t, none.RequirePermission("contents", "read"), "missing contents:read permission")
+}
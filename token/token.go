@@ -24,6 +24,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
 	"time"
 
 	"github.com/jaredallard/vcs"
@@ -65,7 +66,8 @@ type Options struct {
 
 	// UseGlobalCache allows for the use of a global cache for tokens. If
 	// set to true, the token will be cached globally (all instances of
-	// this library). Otherwise, the token will always be fetched.
+	// this library). Otherwise, the token will always be fetched and the
+	// cache is neither read from nor written to.
 	//
 	// Defaults to true.
 	//
@@ -85,7 +87,7 @@ type Options struct {
 // optss is a variadic argument only to avoid a breaking change. Only
 // one option struct is allowed, an error will be returned if more than
 // one is provided.
-func Fetch(_ context.Context, vcsp vcs.Provider, allowUnauthenticated bool, optss ...*Options) (*shared.Token, error) {
+func Fetch(ctx context.Context, vcsp vcs.Provider, allowUnauthenticated bool, optss ...*Options) (*shared.Token, error) {
 	if _, ok := defaultProviders[vcsp]; !ok {
 		return nil, fmt.Errorf("unknown VCS provider %q", vcsp)
 	}
@@ -110,9 +112,15 @@ func Fetch(_ context.Context, vcsp vcs.Provider, allowUnauthenticated bool, opts
 		opts.UseGlobalCache = &b
 	}
 
+	var cache CacheBackend
 	if *opts.UseGlobalCache {
-		t, ok := cache.Get(vcsp)
-		if ok {
+		var err error
+		cache, err = getCache()
+		if err != nil {
+			return nil, err
+		}
+
+		if t, ok := cache.Get(vcsp); ok {
 			return t.Clone(), nil
 		}
 	}
@@ -142,10 +150,22 @@ func Fetch(_ context.Context, vcsp vcs.Provider, allowUnauthenticated bool, opts
 		token = &shared.Token{}
 	}
 
+	// When running in Github Actions, determine the permissions of the
+	// GITHUB_TOKEN so consumers can fail early if they're insufficient.
+	// This is best-effort, permissions are left unknown on failure.
+	if vcsp == vcs.ProviderGithub && os.Getenv("GITHUB_ACTIONS") == "true" &&
+		token.Source == "environment variable (GITHUB_TOKEN)" {
+		if perms, err := github.Permissions(ctx, token); err == nil {
+			token.Permissions = perms
+		}
+	}
+
 	// Set when the token was fetched and store it in the cache for
 	// possibly other calls to use.
 	token.FetchedAt = time.Now()
-	cache.Set(vcsp, token)
+	if cache != nil {
+		cache.Set(vcsp, token)
+	}
 
 	return token, nil
 }
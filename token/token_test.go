@@ -1,8 +1,12 @@
 package token_test
 
 import (
+	"bytes"
 	"context"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"runtime"
 	"testing"
 	"time"
 
@@ -35,10 +39,10 @@ func TestCanGetToken(t *testing.T) {
 // TestCanGetCachedToken ensures that [token.Fetch] returns the same
 // token when called multiple times and caching is enabled.
 func TestCanGetCachedToken(t *testing.T) {
-	bfalse := false
+	assert.NilError(t, token.SetCacheBackend(token.NewMemoryCacheBackend()))
 	t.Setenv("GITHUB_TOKEN", time.Now().String())
 
-	originalToken, err := token.Fetch(context.Background(), vcs.ProviderGithub, false, &token.Options{UseGlobalCache: &bfalse})
+	originalToken, err := token.Fetch(context.Background(), vcs.ProviderGithub, false)
 	assert.NilError(t, err)
 	assert.Assert(t, originalToken != nil, "expected a token to be returned")
 	assert.DeepEqual(t, originalToken, &token.Token{
@@ -57,3 +61,126 @@ func TestCanGetCachedToken(t *testing.T) {
 		Value:     os.Getenv("GITHUB_TOKEN"),
 	})
 }
+
+// TestCanSkipGlobalCache ensures that [token.Fetch] neither reads from
+// nor writes to the cache when UseGlobalCache is false, even if the
+// cache backend is refused by the policy.
+func TestCanSkipGlobalCache(t *testing.T) {
+	t.Cleanup(func() {
+		token.SetPolicy(token.Policy{})
+		assert.NilError(t, token.SetCacheBackend(token.NewMemoryCacheBackend()))
+	})
+	assert.NilError(t, token.SetCacheBackend(insecureCache{token.NewMemoryCacheBackend()}))
+	token.SetPolicy(token.Policy{RequireSecureCache: true})
+
+	bfalse := false
+	t.Setenv("GITHUB_TOKEN", "uncached")
+	tok, err := token.Fetch(context.Background(), vcs.ProviderGithub, false, &token.Options{UseGlobalCache: &bfalse})
+	assert.NilError(t, err)
+	assert.Equal(t, tok.Value, "uncached")
+
+	token.SetPolicy(token.Policy{})
+	t.Setenv("GITHUB_TOKEN", "cached")
+	tok, err = token.Fetch(context.Background(), vcs.ProviderGithub, false)
+	assert.NilError(t, err)
+	assert.Equal(t, tok.Value, "cached")
+}
+
+// TestCommandInjectsAndRedactsToken ensures that [token.Command] passes
+// the token to the subprocess and redacts it from the output.
+func TestCommandInjectsAndRedactsToken(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh to print the environment")
+	}
+
+	tok := &token.Token{Value: "super-secret-token"}
+
+	cmd := token.Command(context.Background(), tok, vcs.ProviderGithub, "sh", "-c", "echo $GH_TOKEN")
+	out, err := cmd.Output()
+	assert.NilError(t, err)
+	assert.Equal(t, string(out), "[REDACTED]\n")
+
+	// Short tokens are redacted entirely too.
+	short := &token.Token{Value: "abcd"}
+	cmd = token.Command(context.Background(), short, vcs.ProviderGithub, "sh", "-c", "echo $GH_TOKEN")
+	out, err = cmd.Output()
+	assert.NilError(t, err)
+	assert.Equal(t, string(out), "[REDACTED]\n")
+
+	var stderr bytes.Buffer
+	cmd = token.Command(context.Background(), tok, vcs.ProviderGithub, "sh", "-c", "printf $GITHUB_TOKEN >&2")
+	cmd.SetStderr(&stderr)
+	assert.NilError(t, cmd.Run())
+	assert.Equal(t, stderr.String(), "[REDACTED]")
+}
+
+// TestFetchesActionsTokenPermissions ensures that [token.Fetch] attaches
+// the permissions of a GITHUB_TOKEN when running in Github Actions.
+func TestFetchesActionsTokenPermissions(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/installation/repositories" || r.Header.Get("Authorization") != "Bearer actions-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		w.Write([]byte(`{"repositories":[{"full_name":"jaredallard/vcs","permissions":{"pull":true,"push":false}}]}`))
+	}))
+	defer srv.Close()
+
+	t.Setenv("GITHUB_ACTIONS", "true")
+	t.Setenv("GITHUB_API_URL", srv.URL)
+	t.Setenv("GITHUB_REPOSITORY", "jaredallard/vcs")
+	t.Setenv("GITHUB_TOKEN", "actions-token")
+
+	bfalse := false
+	tok, err := token.Fetch(context.Background(), vcs.ProviderGithub, false, &token.Options{UseGlobalCache: &bfalse})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, tok.Permissions, map[string]string{"contents": "read"})
+	assert.NilError(t, tok.RequirePermission("contents", "read"))
+	assert.Error(t, tok.RequirePermission("contents", "write"), "missing contents:write permission")
+}
+
+// insecureCache is a [token.CacheBackend] that claims to persist tokens
+// in plaintext.
+type insecureCache struct {
+	token.CacheBackend
+}
+
+// Secure implements [token.CacheBackend].
+func (insecureCache) Secure() bool {
+	return false
+}
+
+// TestPolicyRefusesInsecureCache ensures that insecure cache backends
+// are refused when the policy requires a secure cache.
+func TestPolicyRefusesInsecureCache(t *testing.T) {
+	t.Cleanup(func() {
+		token.SetPolicy(token.Policy{})
+		assert.NilError(t, token.SetCacheBackend(token.NewMemoryCacheBackend()))
+	})
+	t.Setenv("GITHUB_TOKEN", time.Now().String())
+
+	// Insecure backends are allowed by default.
+	assert.NilError(t, token.SetCacheBackend(insecureCache{token.NewMemoryCacheBackend()}))
+	_, err := token.Fetch(context.Background(), vcs.ProviderGithub, false)
+	assert.NilError(t, err)
+
+	// But are verified at runtime once the policy is set.
+	token.SetPolicy(token.Policy{RequireSecureCache: true})
+	_, err = token.Fetch(context.Background(), vcs.ProviderGithub, false)
+	assert.ErrorIs(t, err, token.ErrInsecureCache)
+
+	// And can no longer be configured.
+	assert.ErrorIs(t, token.SetCacheBackend(insecureCache{token.NewMemoryCacheBackend()}), token.ErrInsecureCache)
+	assert.NilError(t, token.SetCacheBackend(token.NewMemoryCacheBackend()))
+	_, err = token.Fetch(context.Background(), vcs.ProviderGithub, false)
+	assert.NilError(t, err)
+}
+
+// TestSetCacheBackendRefusesNil ensures that a nil cache backend can't
+// be configured.
+func TestSetCacheBackendRefusesNil(t *testing.T) {
+	assert.ErrorContains(t, token.SetCacheBackend(nil), "must not be nil")
+	_, err := token.Fetch(context.Background(), vcs.ProviderGithub, true)
+	assert.NilError(t, err)
+}
@@ -22,7 +22,12 @@ package vcs
 
 import (
 	"fmt"
+	"path"
+	"regexp"
+	"slices"
 	"strings"
+
+	giturls "github.com/chainguard-dev/git-urls"
 )
 
 // Provider represents a VCS provider.
@@ -47,6 +52,87 @@ type Override struct {
 	Provider Provider
 }
 
+// scpLikeIPv6Pattern matches SCP-like Git URLs with IPv6 hosts (e.g.,
+// git@[::1]:org/repo), which are not supported by giturls.
+var scpLikeIPv6Pattern = regexp.MustCompile(`^(?:[^@/]+@)?\[([0-9a-fA-F:.%]+)\]:(.*)$`)
+
+// RepoURL is a parsed repository URL. See [ParseRepoURL].
+type RepoURL struct {
+	// Scheme is the lowercased scheme of the repository URL (e.g.,
+	// "https" or "ssh"). SCP-like URLs use "ssh" and URLs without a
+	// scheme use "https".
+	Scheme string
+
+	// Host is the lowercased hostname of the repository, without a port.
+	// IPv6 addresses are not wrapped in brackets.
+	Host string
+
+	// Port is the port of the repository, if one was provided.
+	Port string
+
+	// Path is the path of the repository without leading or trailing
+	// slashes and without a ".git" suffix (e.g., "group/subgroup/repo").
+	Path string
+}
+
+// Owner returns the owner of the repository, which is everything but
+// the last element of the path (e.g., "group/subgroup").
+func (u *RepoURL) Owner() string {
+	owner, _ := path.Split(u.Path)
+	return strings.TrimSuffix(owner, "/")
+}
+
+// Name returns the name of the repository, which is the last element
+// of the path.
+func (u *RepoURL) Name() string {
+	return path.Base(u.Path)
+}
+
+// ParseRepoURL parses a remote repository URL. HTTP(S), Git, SSH and
+// SCP-like (git@host:org/repo) URLs are supported. URLs without a
+// scheme (e.g., github.com/org/repo) are assumed to be HTTPS. Local
+// paths are not supported.
+func ParseRepoURL(rawURL string) (*RepoURL, error) {
+	var scheme, host, port, p string
+	if matches := scpLikeIPv6Pattern.FindStringSubmatch(rawURL); matches != nil {
+		scheme, host, p = "ssh", matches[1], matches[2]
+	} else {
+		// Assume URLs that look like they start with a hostname are HTTPS
+		// URLs, e.g., github.com/org/repo.
+		if first, _, ok := strings.Cut(rawURL, "/"); ok && !strings.Contains(rawURL, ":") &&
+			strings.Contains(first, ".") && !strings.HasPrefix(first, ".") {
+			rawURL = "https://" + rawURL
+		}
+
+		u, err := giturls.Parse(rawURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse repository URL %q: %w", rawURL, err)
+		}
+		if u.Scheme == "file" {
+			return nil, fmt.Errorf("repository URL %q is not a remote URL", rawURL)
+		}
+		scheme, host, port, p = strings.ToLower(u.Scheme), u.Hostname(), u.Port(), u.Path
+	}
+
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "" {
+		return nil, fmt.Errorf("repository URL %q has no host", rawURL)
+	}
+
+	p = strings.TrimSuffix(strings.Trim(p, "/"), ".git")
+	if p == "" {
+		return nil, fmt.Errorf("repository URL %q has no path", rawURL)
+	}
+
+	return &RepoURL{Scheme: scheme, Host: host, Port: port, Path: p}, nil
+}
+
+// isHostOrSubdomain returns true if host is domain or a subdomain of
+// it.
+func isHostOrSubdomain(host, domain string) bool {
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
 // ProviderFromURL returns the VCS provider from a URL.
 func ProviderFromURL(url string, overrides []Override) (Provider, error) {
 	// Check for overrides.
@@ -56,14 +142,21 @@ func ProviderFromURL(url string, overrides []Override) (Provider, error) {
 		}
 	}
 
-	// Otherwise, fallback to heuristics.
+	u, err := ParseRepoURL(url)
+	if err != nil {
+		return "", fmt.Errorf("unknown VCS provider for URL: %s: %w", url, err)
+	}
+
+	// Otherwise, fallback to heuristics based on the host.
+	labels := strings.Split(u.Host, ".")
 	switch {
-	case strings.Contains(url, "github.com"):
+	case isHostOrSubdomain(u.Host, "github.com"):
 		return ProviderGithub, nil
-	case strings.Contains(url, "gitlab.com"):
+	case isHostOrSubdomain(u.Host, "gitlab.com"):
 		return ProviderGitlab, nil
-	case strings.Contains(url, "gitlab."):
-		// Support gitlab.xyz addresses.
+	case slices.Contains(labels[:len(labels)-1], "gitlab"):
+		// Support self-hosted Gitlab instances, e.g., gitlab.xyz or
+		// gitlab.example.com.
 		return ProviderGitlab, nil
 	default:
 		return "", fmt.Errorf("unknown VCS provider for URL: %s", url)